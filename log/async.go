@@ -0,0 +1,223 @@
+package log
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OverflowPolicy decides what an AsyncWriter does when its buffer is full.
+type OverflowPolicy string
+
+const (
+	// OverflowBlock waits until there is room in the buffer.
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropOldest discards the oldest buffered entry.
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+	// OverflowDropNewest discards the entry being written.
+	OverflowDropNewest OverflowPolicy = "drop_newest"
+)
+
+const (
+	defaultAsyncBufferSize    = 1024
+	defaultAsyncFlushInterval = time.Second
+	asyncWriteBufferSize      = 256 * 1024
+)
+
+var ErrAsyncWriterClosed = errors.New("async writer closed")
+
+// AsyncConfig configures an AsyncWriter.
+type AsyncConfig struct {
+	// BufferSize is the number of entries buffered before OverflowPolicy
+	// applies. Defaults to 1024.
+	BufferSize int
+	// FlushInterval is how often buffered output is flushed. Defaults to one
+	// second.
+	FlushInterval time.Duration
+	// OverflowPolicy defaults to OverflowBlock.
+	OverflowPolicy OverflowPolicy
+}
+
+// AsyncWriter moves writes to the wrapped writer off the caller's goroutine.
+// Entries are queued and written by a background goroutine which flushes
+// every FlushInterval, on Sync and on Close.
+type AsyncWriter struct {
+	out    io.Writer
+	policy OverflowPolicy
+
+	queue   chan []byte
+	flushes chan chan error
+	done    chan struct{}
+	stopped chan struct{}
+
+	closeOnce sync.Once
+	dropped   atomic.Uint64
+}
+
+func NewAsyncWriter(out io.Writer, c AsyncConfig) *AsyncWriter {
+	bufferSize := c.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultAsyncBufferSize
+	}
+
+	flushInterval := c.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = defaultAsyncFlushInterval
+	}
+
+	policy := c.OverflowPolicy
+	if policy == "" {
+		policy = OverflowBlock
+	}
+
+	aw := &AsyncWriter{
+		out:     out,
+		policy:  policy,
+		queue:   make(chan []byte, bufferSize),
+		flushes: make(chan chan error),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	go aw.run(flushInterval)
+
+	return aw
+}
+
+// Write queues a copy of p. It never returns a write error of the wrapped
+// writer; those are reported by Sync.
+func (aw *AsyncWriter) Write(p []byte) (int, error) {
+	select {
+	case <-aw.done:
+		return 0, ErrAsyncWriterClosed
+	default:
+	}
+
+	entry := make([]byte, len(p))
+	copy(entry, p)
+
+	switch aw.policy {
+	case OverflowDropNewest:
+		select {
+		case aw.queue <- entry:
+		default:
+			aw.dropped.Add(1)
+		}
+
+	case OverflowDropOldest:
+		for {
+			select {
+			case aw.queue <- entry:
+				return len(p), nil
+			default:
+			}
+
+			select {
+			case <-aw.queue:
+				aw.dropped.Add(1)
+			default:
+			}
+		}
+
+	default:
+		select {
+		case aw.queue <- entry:
+		case <-aw.done:
+			return 0, ErrAsyncWriterClosed
+		}
+	}
+
+	return len(p), nil
+}
+
+// Sync writes every queued entry and flushes the wrapped writer.
+func (aw *AsyncWriter) Sync() error {
+	result := make(chan error, 1)
+
+	select {
+	case aw.flushes <- result:
+		return <-result
+	case <-aw.stopped:
+		return nil
+	}
+}
+
+// Close writes every queued entry, flushes and stops the background
+// goroutine. The wrapped writer is not closed.
+func (aw *AsyncWriter) Close() error {
+	err := aw.Sync()
+
+	aw.closeOnce.Do(func() {
+		close(aw.done)
+	})
+
+	<-aw.stopped
+
+	return err
+}
+
+// Dropped returns the number of entries discarded because the buffer was
+// full.
+func (aw *AsyncWriter) Dropped() uint64 {
+	return aw.dropped.Load()
+}
+
+func (aw *AsyncWriter) run(flushInterval time.Duration) {
+	defer close(aw.stopped)
+
+	writer := bufio.NewWriterSize(aw.out, asyncWriteBufferSize)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	var writeErr error
+
+	write := func(entry []byte) {
+		if _, err := writer.Write(entry); err != nil && writeErr == nil {
+			writeErr = err
+		}
+	}
+
+	drain := func() error {
+		for {
+			select {
+			case entry := <-aw.queue:
+				write(entry)
+			default:
+				err := writer.Flush()
+				if syncer, ok := aw.out.(interface{ Sync() error }); ok && err == nil {
+					err = syncer.Sync()
+				}
+
+				if writeErr != nil {
+					err, writeErr = writeErr, nil
+				}
+
+				return err
+			}
+		}
+	}
+
+	for {
+		select {
+		case entry := <-aw.queue:
+			write(entry)
+
+		case <-ticker.C:
+			if err := writer.Flush(); err != nil && writeErr == nil {
+				writeErr = err
+			}
+
+		case result := <-aw.flushes:
+			result <- drain()
+
+		case <-aw.done:
+			_ = drain()
+
+			return
+		}
+	}
+}
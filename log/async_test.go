@@ -0,0 +1,197 @@
+package log_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ranefattesingh/pkg/log"
+	"go.uber.org/zap"
+)
+
+// blockingWriter blocks every Write until release is closed and closes
+// entered when the first Write starts.
+type blockingWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	once    sync.Once
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.entered) })
+	<-w.release
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.buf.String()
+}
+
+func TestAsyncWriter(t *testing.T) {
+	t.Parallel()
+
+	testTable := map[string]struct {
+		policy          log.OverflowPolicy
+		expectedDropped uint64
+		expected        string
+	}{
+		"should keep oldest entries when dropping newest": {log.OverflowDropNewest, 2, "0\n1\n2\n"},
+		"should keep newest entries when dropping oldest": {log.OverflowDropOldest, 2, "0\n3\n4\n"},
+	}
+
+	for scenario, tc := range testTable {
+		t.Run(scenario, func(t *testing.T) {
+			t.Parallel()
+
+			out := &blockingWriter{entered: make(chan struct{}), release: make(chan struct{})}
+			aw := log.NewAsyncWriter(out, log.AsyncConfig{
+				BufferSize:     2,
+				FlushInterval:  time.Millisecond,
+				OverflowPolicy: tc.policy,
+			})
+
+			// Wait until the background goroutine is stuck flushing the
+			// first entry, so the following ones fill the buffer.
+			if _, err := aw.Write([]byte("0\n")); err != nil {
+				t.Fatalf("Write() err = %v", err)
+			}
+
+			<-out.entered
+
+			for _, entry := range []string{"1\n", "2\n", "3\n", "4\n"} {
+				if _, err := aw.Write([]byte(entry)); err != nil {
+					t.Fatalf("Write() err = %v", err)
+				}
+			}
+
+			if dropped := aw.Dropped(); dropped != tc.expectedDropped {
+				t.Errorf("Dropped() = %d, expected %d", dropped, tc.expectedDropped)
+			}
+
+			close(out.release)
+
+			if err := aw.Close(); err != nil {
+				t.Fatalf("Close() err = %v", err)
+			}
+
+			if got := out.String(); got != tc.expected {
+				t.Errorf("output = %q, expected %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestAsyncLoggerSync(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	instance, err := log.New(log.Config{
+		Output: &buf,
+		Async:  &log.AsyncConfig{FlushInterval: time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+	defer instance.Close()
+
+	instance.Logger().Info("buffered")
+
+	if err := instance.Sync(); err != nil {
+		t.Fatalf("Sync() err = %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "buffered") {
+		t.Errorf("output = %q, expected entry after Sync", buf.String())
+	}
+}
+
+func BenchmarkLogger(b *testing.B) {
+	benchmarks := map[string]*log.AsyncConfig{
+		"sync":  nil,
+		"async": {BufferSize: 8192, OverflowPolicy: log.OverflowBlock},
+	}
+
+	for name, async := range benchmarks {
+		b.Run(name, func(b *testing.B) {
+			file, err := os.Create(filepath.Join(b.TempDir(), "bench.log"))
+			if err != nil {
+				b.Fatalf("Create() err = %v", err)
+			}
+			defer file.Close()
+
+			instance, err := log.New(log.Config{Output: file, Async: async})
+			if err != nil {
+				b.Fatalf("New() err = %v", err)
+			}
+
+			logger := instance.Logger()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					logger.Info("request handled", zap.String("path", "/users"), zap.Int("status", 200))
+				}
+			})
+
+			b.StopTimer()
+
+			// Before the deferred file.Close, which the flush writes to.
+			if err := instance.Close(); err != nil {
+				b.Fatalf("Close() err = %v", err)
+			}
+		})
+	}
+}
+
+func TestInstanceDropped(t *testing.T) {
+	t.Parallel()
+
+	out := &blockingWriter{entered: make(chan struct{}), release: make(chan struct{})}
+
+	instance, err := log.New(log.Config{
+		Sinks: []log.Sink{{Output: out}, {Output: out}},
+		Async: &log.AsyncConfig{
+			BufferSize:     1,
+			FlushInterval:  time.Millisecond,
+			OverflowPolicy: log.OverflowDropNewest,
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+
+	instance.Logger().Info("0")
+
+	// One sink is stuck writing the first entry, the other one may be too.
+	<-out.entered
+
+	for i := 0; i < 5; i++ {
+		instance.Logger().Info("overflow")
+	}
+
+	if dropped := instance.Dropped(); dropped < 4 {
+		t.Errorf("Dropped() = %d, expected at least 4", dropped)
+	}
+
+	close(out.release)
+
+	if err := instance.Close(); err != nil {
+		t.Fatalf("Close() err = %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package log
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Causes returns a field named key+".causes" describing the errors wrapped by
+// err with %w or errors.Join. Errors wrapped with %w are listed in order;
+// joined errors are listed with their own causes nested. It returns
+// zap.Skip() if err wraps nothing.
+func Causes(key string, err error) zap.Field {
+	causes := errorCauses(unwrap(err))
+	if len(causes) == 0 {
+		return zap.Skip()
+	}
+
+	return zap.Array(key+".causes", causes)
+}
+
+func unwrap(err error) []error {
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		if cause := e.Unwrap(); cause != nil {
+			return []error{cause}
+		}
+	case interface{ Unwrap() []error }:
+		return e.Unwrap()
+	}
+
+	return nil
+}
+
+// errorCauses flattens chains of single wrapped errors, so that
+// fmt.Errorf("a: %w", fmt.Errorf("b: %w", c)) reads as [b, c] rather than
+// nesting one level per wrap.
+type errorCauses []error
+
+func (c errorCauses) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, err := range c {
+		for err != nil {
+			if err := enc.AppendObject(errorCause{err}); err != nil {
+				return err
+			}
+
+			next := unwrap(err)
+			if len(next) != 1 {
+				break
+			}
+
+			err = next[0]
+		}
+	}
+
+	return nil
+}
+
+type errorCause struct {
+	err error
+}
+
+func (c errorCause) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("message", c.err.Error())
+	enc.AddString("type", fmt.Sprintf("%T", c.err))
+
+	if joined, ok := c.err.(interface{ Unwrap() []error }); ok {
+		return enc.AddArray("causes", errorCauses(joined.Unwrap()))
+	}
+
+	return nil
+}
+
+// errorCausesCore adds a Causes field after every error field written to the
+// wrapped core.
+type errorCausesCore struct {
+	zapcore.Core
+}
+
+func (c errorCausesCore) With(fields []zapcore.Field) zapcore.Core {
+	return errorCausesCore{Core: c.Core.With(withCauses(fields))}
+}
+
+func (c errorCausesCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c errorCausesCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, withCauses(fields))
+}
+
+func withCauses(fields []zapcore.Field) []zapcore.Field {
+	var result []zapcore.Field
+
+	for i, field := range fields {
+		var causes []error
+
+		if err, ok := field.Interface.(error); ok && field.Type == zapcore.ErrorType {
+			causes = unwrap(err)
+		}
+
+		if result == nil && len(causes) == 0 {
+			continue
+		}
+
+		if result == nil {
+			result = append(make([]zapcore.Field, 0, len(fields)+1), fields[:i]...)
+		}
+
+		result = append(result, field)
+
+		if len(causes) > 0 {
+			result = append(result, zap.Array(field.Key+".causes", errorCauses(causes)))
+		}
+	}
+
+	if result == nil {
+		return fields
+	}
+
+	return result
+}
@@ -0,0 +1,105 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/ranefattesingh/pkg/log"
+	"go.uber.org/zap"
+)
+
+func TestErrorCauses(t *testing.T) {
+	t.Parallel()
+
+	errNotFound := errors.New("not found")
+	errTimeout := errors.New("timeout")
+
+	testTable := map[string]struct {
+		err      error
+		expected any
+	}{
+		"should omit causes of unwrapped errors": {errNotFound, nil},
+		"should flatten wrapped chains": {
+			fmt.Errorf("load user: %w", fmt.Errorf("query: %w", errNotFound)),
+			[]any{
+				map[string]any{"message": "query: not found", "type": "*fmt.wrapError"},
+				map[string]any{"message": "not found", "type": "*errors.errorString"},
+			},
+		},
+		"should nest joined errors": {
+			fmt.Errorf("shutdown: %w", errors.Join(errNotFound, errTimeout)),
+			[]any{
+				map[string]any{
+					"message": "not found\ntimeout",
+					"type":    "*errors.joinError",
+					"causes": []any{
+						map[string]any{"message": "not found", "type": "*errors.errorString"},
+						map[string]any{"message": "timeout", "type": "*errors.errorString"},
+					},
+				},
+			},
+		},
+	}
+
+	for scenario, tc := range testTable {
+		t.Run(scenario, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			instance, err := log.New(log.Config{Output: &buf, ErrorCauses: true, DisableStacktrace: true})
+			if err != nil {
+				t.Fatalf("New() err = %v", err)
+			}
+
+			instance.Logger().Error("failed", zap.Error(tc.err))
+
+			entry := map[string]any{}
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("Unmarshal(%s) err = %v", buf.String(), err)
+			}
+
+			if entry["error"] != tc.err.Error() {
+				t.Errorf("error = %v, expected %q", entry["error"], tc.err.Error())
+			}
+
+			if !reflect.DeepEqual(entry["error.causes"], tc.expected) {
+				t.Errorf("error.causes = %v, expected %v", entry["error.causes"], tc.expected)
+			}
+
+			if _, ok := entry["stacktrace"]; ok {
+				t.Errorf("stacktrace present with DisableStacktrace")
+			}
+		})
+	}
+}
+
+func TestStacktraceLevel(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	instance, err := log.New(log.Config{Output: &buf, StacktraceLevel: log.WarnLevel})
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+
+	instance.Logger().Info("no trace")
+	instance.Logger().Warn("trace")
+
+	decoder := json.NewDecoder(&buf)
+	for _, expected := range []bool{false, true} {
+		entry := map[string]any{}
+		if err := decoder.Decode(&entry); err != nil {
+			t.Fatalf("Decode() err = %v", err)
+		}
+
+		if _, ok := entry["stacktrace"]; ok != expected {
+			t.Errorf("%v has stacktrace = %t, expected %t", entry["message"], ok, expected)
+		}
+	}
+}
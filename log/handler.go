@@ -0,0 +1,88 @@
+package log
+
+import (
+	"net/http"
+
+	"github.com/ranefattesingh/pkg/json"
+)
+
+type levelPayload struct {
+	Level LogLevel `json:"level"`
+	Rules string   `json:"rules,omitempty"`
+}
+
+type levelHandler struct{}
+
+// LevelHandler returns an http.Handler that reports the current log level and
+// level rules on GET and changes them on PUT with a body like
+// {"level":"debug"} or {"rules":"db=debug,http=warn"}.
+func LevelHandler() http.Handler {
+	return levelHandler{}
+}
+
+func (h levelHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		_ = json.EncodeResponseJSON(rw, http.StatusOK, currentLevel())
+
+	case http.MethodPut:
+		payload, err := json.DecodeJSON[levelPayload](r)
+		if err != nil {
+			_ = json.EncodeErrorJSON(rw, badRequest(err.Error()))
+
+			return
+		}
+
+		if payload.Level == "" && payload.Rules == "" {
+			_ = json.EncodeErrorJSON(rw, badRequest("level or rules must be specified"))
+
+			return
+		}
+
+		if _, err := parseLevel(payload.Level); payload.Level != "" && err != nil {
+			_ = json.EncodeErrorJSON(rw, badRequest(err.Error()))
+
+			return
+		}
+
+		if payload.Rules != "" {
+			if err := SetLevelRules(payload.Rules); err != nil {
+				_ = json.EncodeErrorJSON(rw, badRequest(err.Error()))
+
+				return
+			}
+		}
+
+		if payload.Level != "" {
+			if err := SetLevel(payload.Level); err != nil {
+				_ = json.EncodeErrorJSON(rw, badRequest(err.Error()))
+
+				return
+			}
+		}
+
+		_ = json.EncodeResponseJSON(rw, http.StatusOK, currentLevel())
+
+	default:
+		rw.Header().Set("Allow", "GET, PUT")
+		_ = json.EncodeErrorJSON(rw, &json.Error{
+			HTTPStatusCode: http.StatusMethodNotAllowed,
+			Code:           http.StatusMethodNotAllowed,
+			Message:        "only GET and PUT are supported",
+		})
+	}
+}
+
+func currentLevel() levelPayload {
+	return levelPayload{Level: Level(), Rules: LevelRules()}
+}
+
+func badRequest(message string) *json.Error {
+	return &json.Error{
+		HTTPStatusCode: http.StatusBadRequest,
+		Code:           http.StatusBadRequest,
+		Message:        message,
+	}
+}
@@ -0,0 +1,47 @@
+package log_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ranefattesingh/pkg/log"
+)
+
+func TestLevelHandler(t *testing.T) {
+	testTable := map[string]struct {
+		method         string
+		body           string
+		expectedStatus int
+		expectedLevel  log.LogLevel
+	}{
+		"should report current level":     {http.MethodGet, "", http.StatusOK, log.InfoLevel},
+		"should change level":             {http.MethodPut, `{"level":"debug"}`, http.StatusOK, log.DebugLevel},
+		"should reject unknown level":     {http.MethodPut, `{"level":"verbose"}`, http.StatusBadRequest, log.InfoLevel},
+		"should reject empty level":       {http.MethodPut, `{}`, http.StatusBadRequest, log.InfoLevel},
+		"should reject malformed body":    {http.MethodPut, `{`, http.StatusBadRequest, log.InfoLevel},
+		"should reject unsupported verbs": {http.MethodPost, "", http.StatusMethodNotAllowed, log.InfoLevel},
+	}
+
+	for scenario, tc := range testTable {
+		t.Run(scenario, func(t *testing.T) {
+			if err := log.SetLevel(log.InfoLevel); err != nil {
+				t.Fatalf("SetLevel() err = %v", err)
+			}
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, "/log/level", strings.NewReader(tc.body))
+
+			log.LevelHandler().ServeHTTP(rec, req)
+
+			if rec.Code != tc.expectedStatus {
+				t.Errorf("status = %d, expected %d", rec.Code, tc.expectedStatus)
+			}
+
+			if got := log.Level(); got != tc.expectedLevel {
+				t.Errorf("Level() = %q, expected %q", got, tc.expectedLevel)
+			}
+		})
+	}
+}
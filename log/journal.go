@@ -0,0 +1,244 @@
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultJournalSocket = "/run/systemd/journal/socket"
+	// maxJournalFieldName is the longest field name journald accepts.
+	maxJournalFieldName = 64
+)
+
+// JournalConfig configures a sink writing to the systemd journal with its
+// native protocol.
+type JournalConfig struct {
+	// Socket defaults to /run/systemd/journal/socket.
+	Socket string
+	// Identifier is written as SYSLOG_IDENTIFIER and defaults to the name of
+	// the executable.
+	Identifier string
+}
+
+// journalCore writes every field as a journal field, upper-cased and with
+// characters journald does not accept replaced by underscores.
+type journalCore struct {
+	zapcore.LevelEnabler
+	writer   *journalWriter
+	redactor *redactor
+	fields   []zapcore.Field
+}
+
+func (c *journalCore) With(fields []zapcore.Field) zapcore.Core {
+	combined := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	combined = append(combined, c.fields...)
+
+	return &journalCore{
+		LevelEnabler: c.LevelEnabler,
+		writer:       c.writer,
+		redactor:     c.redactor,
+		fields:       append(combined, fields...),
+	}
+}
+
+func (c *journalCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *journalCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+
+	var objectEncoder zapcore.ObjectEncoder = enc
+	if c.redactor != nil {
+		objectEncoder = &redactingObjectEncoder{ObjectEncoder: enc, r: c.redactor}
+	}
+
+	for _, field := range c.fields {
+		field.AddTo(objectEncoder)
+	}
+
+	for _, field := range fields {
+		field.AddTo(objectEncoder)
+	}
+
+	message := ent.Message
+	if c.redactor != nil {
+		message = c.redactor.redactString(message)
+	}
+
+	var b bytes.Buffer
+
+	writeJournalField(&b, "MESSAGE", message)
+	writeJournalField(&b, "PRIORITY", strconv.Itoa(syslogSeverity(ent.Level)))
+	writeJournalField(&b, "SYSLOG_IDENTIFIER", c.writer.identifier)
+
+	if ent.LoggerName != "" {
+		writeJournalField(&b, "LOGGER", ent.LoggerName)
+	}
+
+	if ent.Caller.Defined {
+		writeJournalField(&b, "CODE_FILE", ent.Caller.File)
+		writeJournalField(&b, "CODE_LINE", strconv.Itoa(ent.Caller.Line))
+		writeJournalField(&b, "CODE_FUNC", ent.Caller.Function)
+	}
+
+	if ent.Stack != "" {
+		writeJournalField(&b, "STACKTRACE", ent.Stack)
+	}
+
+	keys := make([]string, 0, len(enc.Fields))
+	for key := range enc.Fields {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		writeJournalField(&b, journalFieldName(key), journalFieldValue(enc.Fields[key]))
+	}
+
+	return c.writer.write(b.Bytes())
+}
+
+func (c *journalCore) Sync() error {
+	return nil
+}
+
+type journalWriter struct {
+	identifier string
+
+	mu   sync.Mutex
+	conn *net.UnixConn
+	addr *net.UnixAddr
+}
+
+func newJournalWriter(c JournalConfig) (*journalWriter, error) {
+	socket := c.Socket
+	if socket == "" {
+		socket = defaultJournalSocket
+	}
+
+	identifier := c.Identifier
+	if identifier == "" {
+		identifier = filepath.Base(os.Args[0])
+	}
+
+	// Fail early if the journal is not running.
+	if _, err := os.Stat(socket); err != nil {
+		return nil, err
+	}
+
+	// The socket is left unconnected so that writes keep working after the
+	// journal restarts.
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+
+	return &journalWriter{
+		identifier: identifier,
+		conn:       conn,
+		addr:       &net.UnixAddr{Name: socket, Net: "unixgram"},
+	}, nil
+}
+
+func (w *journalWriter) write(datagram []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, _, err := w.conn.WriteMsgUnix(datagram, nil, w.addr)
+	if isMessageTooLarge(err) {
+		return w.writeFile(datagram)
+	}
+
+	return err
+}
+
+// Close closes the socket used to write to the journal.
+func (w *journalWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.conn.Close()
+}
+
+// writeJournalField appends a field in the native protocol format. Values
+// containing newlines use the length prefixed binary form.
+func writeJournalField(b *bytes.Buffer, name, value string) {
+	b.WriteString(name)
+
+	if !strings.Contains(value, "\n") {
+		b.WriteByte('=')
+		b.WriteString(value)
+		b.WriteByte('\n')
+
+		return
+	}
+
+	b.WriteByte('\n')
+	_ = binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+// journalFieldName upper-cases key and replaces characters other than
+// A-Z, 0-9 and _ with _. Leading underscores are reserved for trusted
+// fields and are dropped, and names are cut to 64 characters.
+func journalFieldName(key string) string {
+	b := make([]byte, 0, len(key))
+	for i := 0; i < len(key); i++ {
+		ch := key[i]
+
+		switch {
+		case ch >= 'a' && ch <= 'z':
+			b = append(b, ch-'a'+'A')
+		case ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+			b = append(b, ch)
+		default:
+			b = append(b, '_')
+		}
+	}
+
+	name := strings.TrimLeft(string(b), "_")
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "F_" + name
+	}
+
+	if len(name) > maxJournalFieldName {
+		name = name[:maxJournalFieldName]
+	}
+
+	return name
+}
+
+func journalFieldValue(v any) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case fmt.Stringer:
+		return value.String()
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+
+		return string(encoded)
+	}
+}
@@ -0,0 +1,39 @@
+//go:build linux
+
+package log
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// writeFile passes datagram to the journal as a sealed memfd, which is how
+// journald accepts entries that do not fit in a datagram.
+func (w *journalWriter) writeFile(datagram []byte) error {
+	fd, err := unix.MemfdCreate("journal-entry", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return err
+	}
+
+	file := os.NewFile(uintptr(fd), "journal-entry")
+	defer file.Close()
+
+	if _, err := file.Write(datagram); err != nil {
+		return err
+	}
+
+	seals := unix.F_SEAL_SHRINK | unix.F_SEAL_GROW | unix.F_SEAL_WRITE | unix.F_SEAL_SEAL
+	if _, err := unix.FcntlInt(uintptr(fd), unix.F_ADD_SEALS, seals); err != nil {
+		return err
+	}
+
+	_, _, err = w.conn.WriteMsgUnix(nil, unix.UnixRights(fd), w.addr)
+
+	return err
+}
+
+func isMessageTooLarge(err error) bool {
+	return errors.Is(err, unix.EMSGSIZE) || errors.Is(err, unix.ENOBUFS)
+}
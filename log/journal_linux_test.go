@@ -0,0 +1,75 @@
+//go:build linux
+
+package log_test
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ranefattesingh/pkg/log"
+	"go.uber.org/zap"
+)
+
+func TestJournalSinkLargeEntry(t *testing.T) {
+	t.Parallel()
+
+	socket := filepath.Join(t.TempDir(), "journal.sock")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("ListenUnixgram() err = %v", err)
+	}
+	defer conn.Close()
+
+	instance, err := log.New(log.Config{
+		Sinks: []log.Sink{{Journal: &log.JournalConfig{Socket: socket, Identifier: "test-app"}}},
+	})
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+	defer instance.Close()
+
+	payload := strings.Repeat("x", 4<<20)
+
+	instance.Logger().Info("large", zap.String("payload", payload))
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	oob := make([]byte, syscall.CmsgSpace(4))
+
+	_, oobn, _, _, err := conn.ReadMsgUnix(nil, oob)
+	if err != nil {
+		t.Fatalf("ReadMsgUnix() err = %v", err)
+	}
+
+	messages, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("ParseSocketControlMessage() = %v, %v, expected one message", messages, err)
+	}
+
+	fds, err := syscall.ParseUnixRights(&messages[0])
+	if err != nil || len(fds) != 1 {
+		t.Fatalf("ParseUnixRights() = %v, %v, expected one descriptor", fds, err)
+	}
+
+	file := os.NewFile(uintptr(fds[0]), "journal-entry")
+	defer file.Close()
+
+	// The descriptor shares the offset left at the end by the writer.
+	datagram, err := io.ReadAll(io.NewSectionReader(file, 0, 1<<30))
+	if err != nil {
+		t.Fatalf("ReadAll() err = %v", err)
+	}
+
+	fields := parseJournal(t, datagram)
+	if fields["MESSAGE"] != "large" || fields["PAYLOAD"] != payload {
+		t.Errorf("MESSAGE = %q, PAYLOAD of %d bytes, expected large and %d bytes",
+			fields["MESSAGE"], len(fields["PAYLOAD"]), len(payload))
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package log
+
+import "errors"
+
+// writeFile is only needed for the journal, which runs on linux.
+func (w *journalWriter) writeFile([]byte) error {
+	return errors.ErrUnsupported
+}
+
+func isMessageTooLarge(error) bool {
+	return false
+}
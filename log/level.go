@@ -22,3 +22,24 @@ const (
 )
 
 type LogLevel string
+
+// SetLevel changes the minimum enabled level of the global logger at runtime.
+func SetLevel(l LogLevel) error {
+	return global.Load().SetLevel(l)
+}
+
+// Level returns the minimum enabled level of the global logger.
+func Level() LogLevel {
+	return global.Load().Level()
+}
+
+// SetLevelRules replaces the level rules of the named loggers of the global
+// logger at runtime, e.g. "db=debug,http=warn,*=info".
+func SetLevelRules(rules string) error {
+	return global.Load().SetLevelRules(rules)
+}
+
+// LevelRules returns the level rules of the global logger.
+func LevelRules() string {
+	return global.Load().LevelRules()
+}
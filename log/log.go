@@ -1,8 +1,11 @@
 package log
 
 import (
+	"errors"
+	"fmt"
 	"io"
-	"os"
+	"sort"
+	"sync/atomic"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -11,60 +14,321 @@ import (
 type Encoder zapcore.Encoder
 
 type Config struct {
-	Output           io.Writer
-	LogLevel         LogLevel
-	Encoder          Encoder
+	Output   io.Writer
+	LogLevel LogLevel
+	Encoder  Encoder
+	// EncoderPreset selects the encoder when Encoder is nil.
+	EncoderPreset EncoderPreset
+	// File, when set, writes to a rotating file instead of Output. Use
+	// NewRotatingFile as a Sink output to combine it with other sinks.
+	File *FileConfig
+	// Sinks, when set, replace Output, File and Encoder. Every entry enabled by
+	// LogLevel is written to each sink whose own level also enables it.
+	Sinks     []Sink
+	Sampling  *SamplingConfig
+	RateLimit *RateLimitConfig
+	// Redaction, when set, masks sensitive data in the output of every sink.
+	Redaction *RedactionConfig
+	// Async, when set, buffers the output of every sink writing to an
+	// io.Writer and writes it from a background goroutine. Syslog and
+	// journal sinks are written directly. Call Sync or Close before exiting
+	// to flush it; Instance.Dropped reports entries lost to overflow.
+	Async *AsyncConfig
+	// LevelRules sets the level of named loggers and their children, e.g.
+	// "db=debug,http=warn,*=info". A "*" rule overrides LogLevel.
+	LevelRules string
+	// StacktraceLevel is the minimum level at which stack traces are captured.
+	// Defaults to error.
+	StacktraceLevel   LogLevel
+	DisableStacktrace bool
+	// ErrorCauses adds an "<key>.causes" array describing the chain of
+	// wrapped and joined errors next to every error field.
+	ErrorCauses      bool
 	AdditionalFields map[string]any
 	IsDevelopment    bool
 }
 
-var log *zap.Logger
+var ErrInvalidLogLevel = errors.New("invalid log level")
 
-func Init(c Config) {
-	if log == nil {
-		log = newZapLogger(c)
+// Instance is a logger built from a Config together with the level that can
+// be changed while it is running.
+type Instance struct {
+	logger *zap.Logger
+	level  zap.AtomicLevel
+	rules  *levelRules
+	// stacktrace is the level stack traces are captured at, nil if disabled.
+	stacktrace  zapcore.LevelEnabler
+	development bool
+	// closers are the files, connections, async writers and rate limiter
+	// timers opened by New, in the order they were opened.
+	closers []io.Closer
+}
+
+// global is swapped atomically, so loggers may be replaced while other
+// goroutines are logging. Until Init or ReplaceGlobals is called it holds a
+// no-op logger.
+var global atomic.Pointer[Instance]
+
+// globalLogger and globalDevelopmentLogger write to whichever logger is
+// global, so loggers derived from them keep working after Init.
+var (
+	globalLogger            = zap.New(globalCore{}, zap.WithCaller(true), zap.AddStacktrace(globalStacktrace{}))
+	globalDevelopmentLogger = globalLogger.WithOptions(zap.Development())
+)
+
+func init() {
+	level := zap.NewAtomicLevel()
+
+	global.Store(&Instance{
+		logger: zap.NewNop(),
+		level:  level,
+		rules:  newLevelRules(level),
+	})
+}
+
+// Init builds a logger from c and installs it as the global logger. It may be
+// called again to replace the global logger; the previous one is closed.
+// Loggers returned by Logger, Named and With before then write to the new
+// one.
+func Init(c Config) error {
+	instance, err := New(c)
+	if err != nil {
+		return err
 	}
+
+	previous := global.Swap(instance)
+	_ = previous.Close()
+
+	return nil
 }
 
-func newZapLogger(c Config) *zap.Logger {
-	output := c.Output
-	if output == nil {
-		output = os.Stdout
+// New builds a logger from c without installing it as the global logger.
+// Call Close when it is no longer used.
+func New(c Config) (_ *Instance, err error) {
+	closers := make([]io.Closer, 0)
+
+	// Release what was opened if a later part of c is invalid.
+	defer func() {
+		if err != nil {
+			closeAll(closers)
+		}
+	}()
+
+	logLevel, err := parseLevel(c.LogLevel)
+	if err != nil {
+		return nil, err
 	}
 
-	if c.Encoder == nil {
-		c.Encoder = DefaultJSONEncoder()
+	level := zap.NewAtomicLevelAt(logLevel)
+
+	rules := newLevelRules(level)
+	if err := rules.set(c.LevelRules); err != nil {
+		return nil, err
 	}
 
-	logLevel, err := zapcore.ParseLevel(string(c.LogLevel))
-	if err != nil {
-		panic("invalid log level")
+	options := []zap.Option{zap.WithCaller(true)}
+
+	var stacktrace zapcore.LevelEnabler
+
+	if !c.DisableStacktrace {
+		stacktraceLevel := zapcore.ErrorLevel
+		if c.StacktraceLevel != "" {
+			stacktraceLevel, err = parseLevel(c.StacktraceLevel)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		stacktrace = stacktraceLevel
+		options = append(options, zap.AddStacktrace(stacktraceLevel))
 	}
 
-	options := []zap.Option{
-		zap.WithCaller(true),
-		zap.AddStacktrace(stacktraceEnabler{}),
+	if len(c.AdditionalFields) > 0 {
+		options = append(options, zap.Fields(fields(c.AdditionalFields)...))
 	}
 
 	if c.IsDevelopment {
 		options = append(options, zap.Development())
 	}
 
-	core := zapcore.NewCore(c.Encoder, zapcore.AddSync(output), zap.NewAtomicLevelAt(logLevel))
-	zapLogger := zap.New(core, options...)
+	sinks := c.Sinks
+	if len(sinks) == 0 {
+		output := c.Output
+		if c.File != nil {
+			file, err := NewRotatingFile(*c.File)
+			if err != nil {
+				return nil, err
+			}
+
+			closers = append(closers, file)
+			output = file
+		}
+
+		sinks = []Sink{{Output: output, Encoder: c.Encoder, EncoderPreset: c.EncoderPreset}}
+	}
+
+	opts := sinkOptions{level: rules, async: c.Async, errorCauses: c.ErrorCauses}
+	if c.Redaction != nil {
+		opts.redactor = newRedactor(*c.Redaction)
+	}
+
+	core, sinkClosers, err := newTeeCore(sinks, opts)
+	closers = append(closers, sinkClosers...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Sampling != nil {
+		core = newSampledCore(core, *c.Sampling)
+	}
+
+	if c.RateLimit != nil {
+		var limiter io.Closer
+
+		core, limiter = newRateLimitedCore(core, *c.RateLimit)
+		closers = append(closers, limiter)
+	}
+
+	return &Instance{
+		logger:      zap.New(ruleCore{Core: core, rules: rules}, options...),
+		level:       level,
+		rules:       rules,
+		stacktrace:  stacktrace,
+		development: c.IsDevelopment,
+		closers:     closers,
+	}, nil
+}
+
+// NewFromCore builds a logger writing to core, filtered by a level and level
+// rules that can be changed like those of loggers built by New. It is meant
+// for tests and custom cores; the output related fields of Config do not
+// apply.
+func NewFromCore(core zapcore.Core, l LogLevel) (*Instance, error) {
+	logLevel, err := parseLevel(l)
+	if err != nil {
+		return nil, err
+	}
+
+	level := zap.NewAtomicLevelAt(logLevel)
+	rules := newLevelRules(level)
+
+	return &Instance{
+		logger: zap.New(ruleCore{Core: core, rules: rules},
+			zap.WithCaller(true),
+			zap.AddStacktrace(zapcore.ErrorLevel),
+		),
+		level:      level,
+		rules:      rules,
+		stacktrace: zapcore.ErrorLevel,
+	}, nil
+}
+
+// Logger returns the underlying zap logger.
+func (i *Instance) Logger() *zap.Logger {
+	return i.logger
+}
+
+// SetLevel changes the minimum enabled level of the logger at runtime.
+func (i *Instance) SetLevel(l LogLevel) error {
+	zapLevel, err := parseLevel(l)
+	if err != nil {
+		return err
+	}
+
+	i.level.SetLevel(zapLevel)
+
+	return nil
+}
+
+// Level returns the minimum enabled level of the logger.
+func (i *Instance) Level() LogLevel {
+	return LogLevel(i.level.Level().String())
+}
+
+// SetLevelRules replaces the level rules of named loggers at runtime. See
+// Config.LevelRules for the format.
+func (i *Instance) SetLevelRules(rules string) error {
+	return i.rules.set(rules)
+}
+
+// LevelRules returns the level rules of named loggers, ending with the "*"
+// rule for every other logger.
+func (i *Instance) LevelRules() string {
+	return i.rules.String()
+}
+
+// Sync flushes any buffered log entries.
+func (i *Instance) Sync() error {
+	return i.logger.Sync()
+}
+
+// Close flushes the logger and closes the files, connections, async writers
+// and rate limiter New opened for it, newest first so pending rate limit
+// summaries and async writers are flushed before the files they write to.
+// Writers passed in Config are not closed. The logger must not be used
+// afterwards.
+func (i *Instance) Close() error {
+	err := i.Sync()
+
+	return errors.Join(err, closeAll(i.closers))
+}
+
+// Dropped returns the number of entries the async writers of the logger
+// discarded because their buffer was full. It is zero without Config.Async.
+func (i *Instance) Dropped() uint64 {
+	var dropped uint64
+
+	for _, closer := range i.closers {
+		if asyncWriter, ok := closer.(*AsyncWriter); ok {
+			dropped += asyncWriter.Dropped()
+		}
+	}
+
+	return dropped
+}
+
+func closeAll(closers []io.Closer) error {
+	errs := make([]error, 0)
+
+	for j := len(closers) - 1; j >= 0; j-- {
+		if err := closers[j].Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ReplaceGlobals installs instance as the global logger and returns a
+// function restoring the previous one.
+func ReplaceGlobals(instance *Instance) func() {
+	previous := global.Swap(instance)
 
-	return zapLogger
+	return func() {
+		ReplaceGlobals(previous)
+	}
 }
 
-type stacktraceEnabler struct{}
+// Dropped returns the number of entries the global logger discarded because
+// its async buffers were full.
+func Dropped() uint64 {
+	return global.Load().Dropped()
+}
 
-func (s stacktraceEnabler) Enabled(level zapcore.Level) bool {
-	switch level {
-	case zap.PanicLevel, zap.DPanicLevel, zapcore.ErrorLevel:
-		return true
-	default:
-		return false
+// Sync flushes any buffered entries of the global logger. Call it before the
+// process exits.
+func Sync() error {
+	return global.Load().Sync()
+}
+
+func parseLevel(l LogLevel) (zapcore.Level, error) {
+	zapLevel, err := zapcore.ParseLevel(string(l))
+	if err != nil {
+		return zapLevel, fmt.Errorf("%w: %q", ErrInvalidLogLevel, l)
 	}
+
+	return zapLevel, nil
 }
 
 func DefaultJSONEncoder() Encoder {
@@ -88,7 +352,7 @@ func DefaultConsoleEncoder() Encoder {
 	return zapcore.NewConsoleEncoder(zapcore.EncoderConfig{
 		TimeKey:        "T",
 		LevelKey:       "L",
-		NameKey:        "L",
+		NameKey:        "N",
 		CallerKey:      "C",
 		MessageKey:     "M",
 		StacktraceKey:  "S",
@@ -101,6 +365,88 @@ func DefaultConsoleEncoder() Encoder {
 	})
 }
 
+// Logger returns the global logger. It never returns nil. The logger, and
+// children derived from it, write to whichever logger is global at the time
+// of each entry, so they may be kept across calls to Init.
 func Logger() *zap.Logger {
-	return log
+	if global.Load().development {
+		return globalDevelopmentLogger
+	}
+
+	return globalLogger
+}
+
+// Named returns a child of the global logger with name appended to its
+// name, e.g. "db" or "http". Like Logger, it follows later calls to Init.
+func Named(name string) *zap.Logger {
+	return Logger().Named(name)
+}
+
+// With returns a child of the global logger with fields attached to every
+// entry it writes. Like Logger, it follows later calls to Init.
+func With(f map[string]any) *zap.Logger {
+	return Logger().With(fields(f)...)
+}
+
+// globalCore writes to the core of the logger that is global at the time of
+// each call, so loggers, handlers and redirects set up before Init follow it.
+type globalCore struct {
+	fields []zapcore.Field
+}
+
+func (c globalCore) current() zapcore.Core {
+	core := global.Load().logger.Core()
+	if len(c.fields) > 0 {
+		core = core.With(c.fields)
+	}
+
+	return core
+}
+
+func (c globalCore) Enabled(l zapcore.Level) bool {
+	return global.Load().logger.Core().Enabled(l)
+}
+
+func (c globalCore) With(fields []zapcore.Field) zapcore.Core {
+	combined := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	combined = append(combined, c.fields...)
+
+	return globalCore{fields: append(combined, fields...)}
+}
+
+func (c globalCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return c.current().Check(ent, ce)
+}
+
+func (c globalCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.current().Write(ent, fields)
+}
+
+func (c globalCore) Sync() error {
+	return global.Load().logger.Core().Sync()
+}
+
+// globalStacktrace captures stack traces at the level of the global logger.
+type globalStacktrace struct{}
+
+func (globalStacktrace) Enabled(l zapcore.Level) bool {
+	stacktrace := global.Load().stacktrace
+
+	return stacktrace != nil && stacktrace.Enabled(l)
+}
+
+func fields(f map[string]any) []zap.Field {
+	keys := make([]string, 0, len(f))
+	for key := range f {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	zapFields := make([]zap.Field, 0, len(keys))
+	for _, key := range keys {
+		zapFields = append(zapFields, zap.Any(key, f[key]))
+	}
+
+	return zapFields
 }
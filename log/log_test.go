@@ -0,0 +1,207 @@
+package log_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ranefattesingh/pkg/log"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	testTable := map[string]struct {
+		config      log.Config
+		expectedErr error
+	}{
+		"should build logger with defaults":   {log.Config{}, nil},
+		"should reject invalid level":         {log.Config{LogLevel: "verbose"}, log.ErrInvalidLogLevel},
+		"should reject invalid sink level":    {log.Config{Sinks: []log.Sink{{LogLevel: "verbose"}}}, log.ErrInvalidLogLevel},
+		"should reject file without filename": {log.Config{File: &log.FileConfig{}}, log.ErrFilenameRequired},
+	}
+
+	for scenario, tc := range testTable {
+		t.Run(scenario, func(t *testing.T) {
+			t.Parallel()
+
+			instance, err := log.New(tc.config)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("New() err = %v, expected %v", err, tc.expectedErr)
+			}
+
+			if err == nil && instance.Logger() == nil {
+				t.Errorf("New() returned nil logger")
+			}
+		})
+	}
+}
+
+func TestNewFieldsAndSinks(t *testing.T) {
+	t.Parallel()
+
+	var debug, info bytes.Buffer
+
+	instance, err := log.New(log.Config{
+		LogLevel: log.DebugLevel,
+		Sinks: []log.Sink{
+			{Output: &debug, Encoder: log.DefaultConsoleEncoder()},
+			{Output: &info, LogLevel: log.InfoLevel},
+		},
+		AdditionalFields: map[string]any{"service": "api"},
+	})
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+
+	logger := instance.Logger().Named("db")
+	logger.Debug("connecting")
+	logger.Info("connected")
+
+	if lines := strings.Count(debug.String(), "\n"); lines != 2 {
+		t.Errorf("debug sink wrote %d lines, expected 2: %s", lines, debug.String())
+	}
+
+	if strings.Contains(info.String(), "connecting") {
+		t.Errorf("info sink wrote debug entry: %s", info.String())
+	}
+
+	for _, expected := range []string{`"logger":"db"`, `"service":"api"`, `"message":"connected"`} {
+		if !strings.Contains(info.String(), expected) {
+			t.Errorf("info sink output %s, expected to contain %s", info.String(), expected)
+		}
+	}
+}
+
+func TestInitAndReplaceGlobals(t *testing.T) {
+	if log.Logger() == nil {
+		t.Fatal("Logger() returned nil before Init")
+	}
+
+	var first, second bytes.Buffer
+
+	discard, err := log.New(log.Config{Output: io.Discard})
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+
+	restore := log.ReplaceGlobals(discard)
+	defer restore()
+
+	if err := log.Init(log.Config{Output: &first}); err != nil {
+		t.Fatalf("Init() err = %v", err)
+	}
+
+	log.Logger().Info("first")
+
+	if err := log.Init(log.Config{Output: &second}); err != nil {
+		t.Fatalf("Init() err = %v", err)
+	}
+
+	log.Logger().Info("second")
+
+	if err := log.Init(log.Config{LogLevel: "verbose"}); !errors.Is(err, log.ErrInvalidLogLevel) {
+		t.Fatalf("Init() err = %v, expected %v", err, log.ErrInvalidLogLevel)
+	}
+
+	log.Named("http").Info("still second")
+
+	if !strings.Contains(first.String(), "first") || strings.Contains(first.String(), "second") {
+		t.Errorf("first output = %s", first.String())
+	}
+
+	if !strings.Contains(second.String(), `"logger":"http"`) {
+		t.Errorf("second output = %s, expected named entry", second.String())
+	}
+}
+
+func TestInstanceClose(t *testing.T) {
+	t.Parallel()
+
+	filename := filepath.Join(t.TempDir(), "app.log")
+
+	instance, err := log.New(log.Config{
+		File:  &log.FileConfig{Filename: filename},
+		Async: &log.AsyncConfig{},
+	})
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+
+	instance.Logger().Info("before close")
+
+	if err := instance.Close(); err != nil {
+		t.Fatalf("Close() err = %v", err)
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("ReadFile() err = %v", err)
+	}
+
+	if !strings.Contains(string(content), "before close") {
+		t.Errorf("output = %s, expected the entry flushed by Close", content)
+	}
+
+	if err := os.Remove(filename); err != nil {
+		t.Fatalf("Remove() err = %v", err)
+	}
+
+	instance.Logger().Info("after close")
+
+	if _, err := os.Stat(filename); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Stat() err = %v, expected the closed file not to be reopened", err)
+	}
+}
+
+func TestInitClosesPrevious(t *testing.T) {
+	dir := t.TempDir()
+
+	discard, err := log.New(log.Config{Output: io.Discard})
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+
+	defer log.ReplaceGlobals(discard)()
+
+	first := filepath.Join(dir, "first.log")
+	if err := log.Init(log.Config{File: &log.FileConfig{Filename: first}}); err != nil {
+		t.Fatalf("Init() err = %v", err)
+	}
+
+	// Taken at startup, as packages commonly do.
+	cached := log.Named("db")
+
+	second := filepath.Join(dir, "second.log")
+	if err := log.Init(log.Config{File: &log.FileConfig{Filename: second}}); err != nil {
+		t.Fatalf("Init() err = %v", err)
+	}
+
+	// Init closes the second logger as well.
+	defer func() {
+		_ = log.Init(log.Config{Output: io.Discard})
+	}()
+
+	if err := os.Remove(first); err != nil {
+		t.Fatalf("Remove() err = %v", err)
+	}
+
+	cached.Info("after init")
+
+	if _, err := os.Stat(first); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Stat() err = %v, expected the previous file to be closed", err)
+	}
+
+	content, err := os.ReadFile(second)
+	if err != nil {
+		t.Fatalf("ReadFile() err = %v", err)
+	}
+
+	if !strings.Contains(string(content), `"logger":"db"`) || !strings.Contains(string(content), "after init") {
+		t.Errorf("second output = %s, expected the entry of the cached logger", content)
+	}
+}
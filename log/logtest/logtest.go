@@ -0,0 +1,160 @@
+// Package logtest captures entries written through the log package so tests
+// can assert on them instead of parsing output.
+package logtest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ranefattesingh/pkg/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// Recorder holds the entries written to the global logger while it is
+// installed.
+type Recorder struct {
+	logs     *observer.ObservedLogs
+	instance *log.Instance
+}
+
+// Filter narrows the entries returned by Recorder.Entries.
+type Filter func(*observer.ObservedLogs) *observer.ObservedLogs
+
+// New installs a Recorder as the global logger at debug level. The previous
+// global logger is restored when t completes, so tests using New must not
+// run in parallel with each other.
+func New(t testing.TB) *Recorder {
+	t.Helper()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	instance, err := log.NewFromCore(core, log.DebugLevel)
+	if err != nil {
+		t.Fatalf("logtest: %v", err)
+	}
+
+	restore := log.ReplaceGlobals(instance)
+	t.Cleanup(restore)
+
+	return &Recorder{logs: logs, instance: instance}
+}
+
+// Instance returns the logger the Recorder captures, e.g. to change its level.
+func (r *Recorder) Instance() *log.Instance {
+	return r.instance
+}
+
+// Entries returns the captured entries matching every filter.
+func (r *Recorder) Entries(filters ...Filter) []observer.LoggedEntry {
+	logs := r.logs
+	for _, filter := range filters {
+		logs = filter(logs)
+	}
+
+	return logs.All()
+}
+
+// Reset discards the captured entries.
+func (r *Recorder) Reset() {
+	r.logs.TakeAll()
+}
+
+// AssertLogged fails t unless an entry with level, msg and all of fields was
+// captured.
+func (r *Recorder) AssertLogged(t testing.TB, level log.LogLevel, msg string, fields ...zap.Field) {
+	t.Helper()
+
+	if len(r.Entries(matching(t, level, msg, fields)...)) == 0 {
+		t.Errorf("logtest: no %s entry %q with fields %v, captured:\n%s",
+			level, msg, fieldMap(fields), r.dump())
+	}
+}
+
+// AssertNotLogged fails t if an entry with level, msg and all of fields was
+// captured.
+func (r *Recorder) AssertNotLogged(t testing.TB, level log.LogLevel, msg string, fields ...zap.Field) {
+	t.Helper()
+
+	if len(r.Entries(matching(t, level, msg, fields)...)) != 0 {
+		t.Errorf("logtest: unexpected %s entry %q with fields %v, captured:\n%s",
+			level, msg, fieldMap(fields), r.dump())
+	}
+}
+
+// Level keeps entries logged at exactly l.
+func Level(l log.LogLevel) Filter {
+	level, err := zapcore.ParseLevel(string(l))
+	if err != nil {
+		panic("logtest: invalid log level " + string(l))
+	}
+
+	return func(logs *observer.ObservedLogs) *observer.ObservedLogs {
+		return logs.FilterLevelExact(level)
+	}
+}
+
+// Message keeps entries whose message is msg.
+func Message(msg string) Filter {
+	return func(logs *observer.ObservedLogs) *observer.ObservedLogs {
+		return logs.FilterMessage(msg)
+	}
+}
+
+// MessageContains keeps entries whose message contains s.
+func MessageContains(s string) Filter {
+	return func(logs *observer.ObservedLogs) *observer.ObservedLogs {
+		return logs.FilterMessageSnippet(s)
+	}
+}
+
+// Field keeps entries carrying f, including fields added with With.
+func Field(f zap.Field) Filter {
+	return func(logs *observer.ObservedLogs) *observer.ObservedLogs {
+		return logs.FilterField(f)
+	}
+}
+
+// LoggerName keeps entries written by the logger named name.
+func LoggerName(name string) Filter {
+	return func(logs *observer.ObservedLogs) *observer.ObservedLogs {
+		return logs.Filter(func(e observer.LoggedEntry) bool {
+			return e.LoggerName == name
+		})
+	}
+}
+
+func matching(t testing.TB, level log.LogLevel, msg string, fields []zap.Field) []Filter {
+	t.Helper()
+
+	if _, err := zapcore.ParseLevel(string(level)); err != nil {
+		t.Fatalf("logtest: invalid log level %q", level)
+	}
+
+	filters := []Filter{Level(level), Message(msg)}
+	for _, f := range fields {
+		filters = append(filters, Field(f))
+	}
+
+	return filters
+}
+
+func (r *Recorder) dump() string {
+	var sb strings.Builder
+	for _, e := range r.logs.All() {
+		fmt.Fprintf(&sb, "\t%s %q %v\n", e.Level, e.Message, e.ContextMap())
+	}
+
+	return sb.String()
+}
+
+func fieldMap(fields []zap.Field) map[string]any {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	return enc.Fields
+}
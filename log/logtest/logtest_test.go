@@ -0,0 +1,54 @@
+package logtest_test
+
+import (
+	"testing"
+
+	"github.com/ranefattesingh/pkg/log"
+	"github.com/ranefattesingh/pkg/log/logtest"
+	"go.uber.org/zap"
+)
+
+func TestRecorder(t *testing.T) {
+	recorder := logtest.New(t)
+
+	log.Named("db").Info("connected", zap.String("host", "localhost"))
+	log.Logger().Debug("query", zap.Int("rows", 3))
+	log.Logger().Warn("slow query")
+
+	recorder.AssertLogged(t, log.InfoLevel, "connected", zap.String("host", "localhost"))
+	recorder.AssertLogged(t, log.DebugLevel, "query", zap.Int("rows", 3))
+	recorder.AssertNotLogged(t, log.ErrorLevel, "slow query")
+
+	if entries := recorder.Entries(logtest.Level(log.WarnLevel)); len(entries) != 1 {
+		t.Errorf("warn entries = %d, expected 1", len(entries))
+	}
+
+	if entries := recorder.Entries(logtest.LoggerName("db"), logtest.MessageContains("conn")); len(entries) != 1 {
+		t.Errorf("db entries = %d, expected 1", len(entries))
+	}
+
+	if err := recorder.Instance().SetLevel(log.InfoLevel); err != nil {
+		t.Fatalf("SetLevel() err = %v", err)
+	}
+
+	recorder.Reset()
+	log.Logger().Debug("hidden")
+
+	if entries := recorder.Entries(); len(entries) != 0 {
+		t.Errorf("entries after Reset = %d, expected 0", len(entries))
+	}
+}
+
+func TestRecorderRestoresGlobalLogger(t *testing.T) {
+	var recorder *logtest.Recorder
+
+	t.Run("installs recorder", func(t *testing.T) {
+		recorder = logtest.New(t)
+	})
+
+	log.Logger().Info("after cleanup")
+
+	if entries := recorder.Entries(); len(entries) != 0 {
+		t.Errorf("recorder captured %d entries after cleanup", len(entries))
+	}
+}
@@ -0,0 +1,245 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const ecsVersion = "1.6.0"
+
+// EncoderPreset selects an encoder that follows the field conventions of a
+// log backend.
+type EncoderPreset string
+
+const (
+	// PresetDefault uses DefaultJSONEncoder.
+	PresetDefault EncoderPreset = ""
+	// PresetConsole uses DefaultConsoleEncoder.
+	PresetConsole EncoderPreset = "console"
+	// PresetECS uses ECSEncoder.
+	PresetECS EncoderPreset = "ecs"
+	// PresetGCP uses GCPEncoder.
+	PresetGCP EncoderPreset = "gcp"
+	// PresetDatadog uses DatadogEncoder.
+	PresetDatadog EncoderPreset = "datadog"
+)
+
+var ErrUnknownEncoderPreset = errors.New("unknown encoder preset")
+
+func presetEncoder(p EncoderPreset) (Encoder, error) {
+	switch p {
+	case PresetDefault:
+		return DefaultJSONEncoder(), nil
+	case PresetConsole:
+		return DefaultConsoleEncoder(), nil
+	case PresetECS:
+		return ECSEncoder(), nil
+	case PresetGCP:
+		return GCPEncoder(), nil
+	case PresetDatadog:
+		return DatadogEncoder(), nil
+	default:
+		return nil, ErrUnknownEncoderPreset
+	}
+}
+
+// ECSEncoder returns a JSON encoder following the Elastic Common Schema.
+// Error fields are written as "<key>.message" and "<key>.type".
+func ECSEncoder() Encoder {
+	encoder := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+		TimeKey:        "@timestamp",
+		LevelKey:       "log.level",
+		NameKey:        "log.logger",
+		CallerKey:      zapcore.OmitKey,
+		MessageKey:     "message",
+		StacktraceKey:  "error.stack_trace",
+		FunctionKey:    zapcore.OmitKey,
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+		EncodeDuration: zapcore.NanosDurationEncoder,
+	})
+	encoder.AddString("ecs.version", ecsVersion)
+
+	return &errorFieldsEncoder{
+		Encoder: &callerEncoder{Encoder: encoder, field: ecsCaller},
+		typeKey: "type",
+	}
+}
+
+// GCPEncoder returns a JSON encoder following the Google Cloud Logging
+// structured logging conventions.
+func GCPEncoder() Encoder {
+	encoder := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+		TimeKey:        "time",
+		LevelKey:       "severity",
+		NameKey:        "logger",
+		CallerKey:      zapcore.OmitKey,
+		MessageKey:     "message",
+		StacktraceKey:  "stack_trace",
+		FunctionKey:    zapcore.OmitKey,
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    gcpLevelEncoder,
+		EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+	})
+
+	return &callerEncoder{Encoder: encoder, field: gcpCaller}
+}
+
+// DatadogEncoder returns a JSON encoder using the Datadog standard
+// attributes. Error fields are written as "<key>.message" and "<key>.kind".
+func DatadogEncoder() Encoder {
+	encoder := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+		TimeKey:        "timestamp",
+		LevelKey:       "status",
+		NameKey:        "logger.name",
+		CallerKey:      "logger.caller",
+		MessageKey:     "message",
+		StacktraceKey:  "error.stack",
+		FunctionKey:    "logger.method_name",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+		EncodeDuration: zapcore.NanosDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	})
+
+	return &errorFieldsEncoder{Encoder: encoder, typeKey: "kind"}
+}
+
+func gcpLevelEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	switch l {
+	case zapcore.DebugLevel:
+		enc.AppendString("DEBUG")
+	case zapcore.InfoLevel:
+		enc.AppendString("INFO")
+	case zapcore.WarnLevel:
+		enc.AppendString("WARNING")
+	case zapcore.ErrorLevel:
+		enc.AppendString("ERROR")
+	case zapcore.DPanicLevel:
+		enc.AppendString("CRITICAL")
+	case zapcore.PanicLevel:
+		enc.AppendString("ALERT")
+	case zapcore.FatalLevel:
+		enc.AppendString("EMERGENCY")
+	default:
+		enc.AppendString("DEFAULT")
+	}
+}
+
+func ecsCaller(caller zapcore.EntryCaller) zapcore.Field {
+	return zap.Object("log.origin", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		enc.AddString("file.name", caller.TrimmedPath())
+		enc.AddInt("file.line", caller.Line)
+		enc.AddString("function", caller.Function)
+
+		return nil
+	}))
+}
+
+func gcpCaller(caller zapcore.EntryCaller) zapcore.Field {
+	return zap.Object("logging.googleapis.com/sourceLocation", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		enc.AddString("file", caller.File)
+		enc.AddString("line", strconv.Itoa(caller.Line))
+		enc.AddString("function", caller.Function)
+
+		return nil
+	}))
+}
+
+// callerEncoder encodes the entry caller as a structured field, for backends
+// that expect an object rather than a "file:line" string.
+type callerEncoder struct {
+	zapcore.Encoder
+	field func(zapcore.EntryCaller) zapcore.Field
+}
+
+func (e *callerEncoder) Clone() zapcore.Encoder {
+	return &callerEncoder{Encoder: e.Encoder.Clone(), field: e.field}
+}
+
+func (e *callerEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	if ent.Caller.Defined {
+		fields = append(fields[:len(fields):len(fields)], e.field(ent.Caller))
+	}
+
+	return e.Encoder.EncodeEntry(ent, fields)
+}
+
+// errorFieldsEncoder writes error fields as "<key>.message" and
+// "<key>.<typeKey>" strings, for backends that keep the stack trace under
+// "error." too and would otherwise see "error" as both a value and an
+// object.
+type errorFieldsEncoder struct {
+	zapcore.Encoder
+	typeKey string
+}
+
+func (e *errorFieldsEncoder) Clone() zapcore.Encoder {
+	return &errorFieldsEncoder{Encoder: e.Encoder.Clone(), typeKey: e.typeKey}
+}
+
+func (e *errorFieldsEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	return e.Encoder.EncodeEntry(ent, mapErrorFields(fields, e.typeKey))
+}
+
+func mapErrorFields(fields []zapcore.Field, typeKey string) []zapcore.Field {
+	var result []zapcore.Field
+
+	for i, field := range fields {
+		err, ok := field.Interface.(error)
+		if !ok || field.Type != zapcore.ErrorType {
+			if result != nil {
+				result = append(result, field)
+			}
+
+			continue
+		}
+
+		if result == nil {
+			result = append(make([]zapcore.Field, 0, len(fields)+1), fields[:i]...)
+		}
+
+		result = append(result,
+			zap.String(field.Key+".message", err.Error()),
+			zap.String(field.Key+"."+typeKey, fmt.Sprintf("%T", err)),
+		)
+	}
+
+	if result == nil {
+		return fields
+	}
+
+	return result
+}
+
+// errorFieldsCore maps error fields like errorFieldsEncoder before they
+// reach the encoder, which it cannot do itself for fields added with With or
+// encoded by a redacting encoder.
+type errorFieldsCore struct {
+	zapcore.Core
+	typeKey string
+}
+
+func (c errorFieldsCore) With(fields []zapcore.Field) zapcore.Core {
+	return errorFieldsCore{Core: c.Core.With(mapErrorFields(fields, c.typeKey)), typeKey: c.typeKey}
+}
+
+func (c errorFieldsCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c errorFieldsCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, mapErrorFields(fields, c.typeKey))
+}
@@ -0,0 +1,149 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ranefattesingh/pkg/log"
+	"go.uber.org/zap"
+)
+
+func TestEncoderPresets(t *testing.T) {
+	t.Parallel()
+
+	testTable := map[string]struct {
+		preset   log.EncoderPreset
+		expected map[string]any
+		present  []string
+	}{
+		"should encode elastic common schema": {
+			log.PresetECS,
+			map[string]any{"log.level": "warn", "message": "disk low", "log.logger": "db", "ecs.version": "1.6.0"},
+			[]string{"@timestamp", "log.origin"},
+		},
+		"should encode google cloud logging": {
+			log.PresetGCP,
+			map[string]any{"severity": "WARNING", "message": "disk low", "logger": "db"},
+			[]string{"time", "logging.googleapis.com/sourceLocation"},
+		},
+		"should encode datadog attributes": {
+			log.PresetDatadog,
+			map[string]any{"status": "warn", "message": "disk low", "logger.name": "db"},
+			[]string{"timestamp", "logger.caller"},
+		},
+	}
+
+	for scenario, tc := range testTable {
+		t.Run(scenario, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			instance, err := log.New(log.Config{Output: &buf, EncoderPreset: tc.preset})
+			if err != nil {
+				t.Fatalf("New() err = %v", err)
+			}
+
+			instance.Logger().Named("db").Warn("disk low")
+
+			entry := map[string]any{}
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("Unmarshal(%s) err = %v", buf.String(), err)
+			}
+
+			for key, value := range tc.expected {
+				if entry[key] != value {
+					t.Errorf("%s = %v, expected %v", key, entry[key], value)
+				}
+			}
+
+			for _, key := range tc.present {
+				if _, ok := entry[key]; !ok {
+					t.Errorf("%s missing from %s", key, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestEncoderPresetErrors(t *testing.T) {
+	t.Parallel()
+
+	testTable := map[string]struct {
+		preset   log.EncoderPreset
+		config   log.Config
+		expected map[string]any
+		stack    string
+	}{
+		"should map error fields to elastic common schema": {
+			preset:   log.PresetECS,
+			expected: map[string]any{"error.message": "connection refused", "error.type": "*errors.errorString"},
+			stack:    "error.stack_trace",
+		},
+		"should map error fields to datadog attributes": {
+			preset:   log.PresetDatadog,
+			expected: map[string]any{"error.message": "connection refused", "error.kind": "*errors.errorString"},
+			stack:    "error.stack",
+		},
+		"should map error fields added with redaction": {
+			preset:   log.PresetECS,
+			config:   log.Config{Redaction: &log.RedactionConfig{}},
+			expected: map[string]any{"error.message": "connection refused", "error.type": "*errors.errorString"},
+			stack:    "error.stack_trace",
+		},
+	}
+
+	for scenario, tc := range testTable {
+		t.Run(scenario, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			config := tc.config
+			config.Output = &buf
+			config.EncoderPreset = tc.preset
+
+			instance, err := log.New(config)
+			if err != nil {
+				t.Fatalf("New() err = %v", err)
+			}
+
+			err = errors.New("connection refused")
+
+			instance.Logger().Error("query failed", zap.Error(err))
+			instance.Logger().With(zap.Error(err)).Error("query failed")
+
+			for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+				entry := map[string]any{}
+				if err := json.Unmarshal(line, &entry); err != nil {
+					t.Fatalf("Unmarshal(%s) err = %v", line, err)
+				}
+
+				if _, ok := entry["error"]; ok {
+					t.Errorf("error written as a plain value in %s", line)
+				}
+
+				if _, ok := entry[tc.stack]; !ok {
+					t.Errorf("%s missing from %s", tc.stack, line)
+				}
+
+				for key, value := range tc.expected {
+					if entry[key] != value {
+						t.Errorf("%s = %v, expected %v", key, entry[key], value)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestUnknownEncoderPreset(t *testing.T) {
+	t.Parallel()
+
+	_, err := log.New(log.Config{EncoderPreset: "splunk"})
+	if !errors.Is(err, log.ErrUnknownEncoderPreset) {
+		t.Errorf("New() err = %v, expected %v", err, log.ErrUnknownEncoderPreset)
+	}
+}
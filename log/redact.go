@@ -0,0 +1,533 @@
+package log
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const defaultRedactionMask = "[REDACTED]"
+
+// RedactionConfig configures masking of sensitive data in encoded entries.
+// Struct fields tagged `log:"redact"` are always masked.
+type RedactionConfig struct {
+	// Fields are matched case-insensitively against the segments of field
+	// keys, split on "_", "-", "." and camel case: "token" masks "api_token"
+	// and "X-Auth-Token" but not "max_tokens".
+	Fields []string
+	// Patterns are masked wherever they match in string values and messages.
+	Patterns []*regexp.Regexp
+	// Mask replaces redacted values. Defaults to "[REDACTED]".
+	Mask string
+}
+
+// cardNumberPattern matches 13 to 19 digits, optionally grouped by spaces or
+// dashes. Matches are only masked if they pass the Luhn check, so that IDs
+// and timestamps are kept.
+const cardNumberPattern = `\b(?:\d[ -]?){12,18}\d\b`
+
+// DefaultRedactionConfig masks passwords, authorization headers, tokens,
+// secrets, bearer tokens, JWTs, card numbers and email addresses.
+func DefaultRedactionConfig() RedactionConfig {
+	return RedactionConfig{
+		Fields: []string{"password", "authorization", "token", "secret"},
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`),
+			regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+			regexp.MustCompile(cardNumberPattern),
+			regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+		},
+	}
+}
+
+// NewRedactingEncoder wraps enc so that every field and message passes
+// through the redaction rules in c before being encoded.
+func NewRedactingEncoder(enc Encoder, c RedactionConfig) Encoder {
+	return newRedactingEncoder(enc, newRedactor(c))
+}
+
+type redactor struct {
+	fields   [][]string
+	patterns []*regexp.Regexp
+	mask     string
+}
+
+func newRedactor(c RedactionConfig) *redactor {
+	fields := make([][]string, 0, len(c.Fields))
+	for _, field := range c.Fields {
+		if segments := keySegments(field); len(segments) > 0 {
+			fields = append(fields, segments)
+		}
+	}
+
+	mask := c.Mask
+	if mask == "" {
+		mask = defaultRedactionMask
+	}
+
+	return &redactor{fields: fields, patterns: c.Patterns, mask: mask}
+}
+
+// redactsKey reports whether the segments of a field appear in key in the
+// same order, next to each other.
+func (r *redactor) redactsKey(key string) bool {
+	segments := keySegments(key)
+
+	for _, field := range r.fields {
+		for i := 0; i+len(field) <= len(segments); i++ {
+			if slices.Equal(segments[i:i+len(field)], field) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// keySegments splits key into lower case words on "_", "-", ".", spaces and
+// lower to upper case changes.
+func keySegments(key string) []string {
+	segments := make([]string, 0, 4)
+
+	var segment strings.Builder
+
+	flush := func() {
+		if segment.Len() > 0 {
+			segments = append(segments, segment.String())
+			segment.Reset()
+		}
+	}
+
+	previousLower := false
+
+	for _, c := range key {
+		switch {
+		case c == '_' || c == '-' || c == '.' || unicode.IsSpace(c):
+			flush()
+
+			previousLower = false
+
+			continue
+		case unicode.IsUpper(c) && previousLower:
+			flush()
+		}
+
+		previousLower = unicode.IsLower(c) || unicode.IsDigit(c)
+		segment.WriteRune(unicode.ToLower(c))
+	}
+
+	flush()
+
+	return segments
+}
+
+func (r *redactor) redactString(s string) string {
+	for _, pattern := range r.patterns {
+		if pattern.String() == cardNumberPattern {
+			s = pattern.ReplaceAllStringFunc(s, func(match string) string {
+				if luhnValid(match) {
+					return r.mask
+				}
+
+				return match
+			})
+
+			continue
+		}
+
+		s = pattern.ReplaceAllLiteralString(s, r.mask)
+	}
+
+	return s
+}
+
+// luhnValid reports whether the digits of number pass the Luhn checksum of
+// card numbers.
+func luhnValid(number string) bool {
+	sum := 0
+	double := false
+
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+
+		digit := int(c - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+
+		sum += digit
+		double = !double
+	}
+
+	return sum%10 == 0
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// sanitize returns a copy of v suitable for reflection based encoding with
+// tagged struct fields, sensitive map keys and matching strings masked.
+// Structs are converted to maps keyed by their JSON names.
+func (r *redactor) sanitize(v any) any {
+	if v == nil {
+		return nil
+	}
+
+	return r.sanitizeValue(reflect.ValueOf(v))
+}
+
+func (r *redactor) sanitizeValue(rv reflect.Value) any {
+	if !rv.IsValid() {
+		return nil
+	}
+
+	if rv.Type().Implements(jsonMarshalerType) || rv.Type().Implements(textMarshalerType) {
+		return rv.Interface()
+	}
+
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+
+		return r.sanitizeValue(rv.Elem())
+
+	case reflect.String:
+		return r.redactString(rv.String())
+
+	case reflect.Struct:
+		return r.sanitizeStruct(rv)
+
+	case reflect.Map:
+		if rv.IsNil() || rv.Type().Key().Kind() != reflect.String {
+			return rv.Interface()
+		}
+
+		result := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+
+		for iter.Next() {
+			key := iter.Key().String()
+			if r.redactsKey(key) {
+				result[key] = r.mask
+			} else {
+				result[key] = r.sanitizeValue(iter.Value())
+			}
+		}
+
+		return result
+
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && (rv.IsNil() || rv.Type().Elem().Kind() == reflect.Uint8) {
+			return rv.Interface()
+		}
+
+		result := make([]any, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			result = append(result, r.sanitizeValue(rv.Index(i)))
+		}
+
+		return result
+
+	default:
+		return rv.Interface()
+	}
+}
+
+func (r *redactor) sanitizeStruct(rv reflect.Value) any {
+	rt := rv.Type()
+	result := make(map[string]any, rt.NumField())
+
+	for i := 0; i < rt.NumField(); i++ {
+		ft := rt.Field(i)
+		if !ft.IsExported() {
+			continue
+		}
+
+		name, omitEmpty, skip := jsonFieldName(ft)
+		if skip {
+			continue
+		}
+
+		fv := rv.Field(i)
+		if omitEmpty && fv.IsZero() {
+			continue
+		}
+
+		if ft.Tag.Get("log") == "redact" || r.redactsKey(name) {
+			result[name] = r.mask
+
+			continue
+		}
+
+		result[name] = r.sanitizeValue(fv)
+	}
+
+	return result
+}
+
+func jsonFieldName(ft reflect.StructField) (name string, omitEmpty bool, skip bool) {
+	tag := ft.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	name, options, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = ft.Name
+	}
+
+	return name, strings.Contains(options, "omitempty"), false
+}
+
+// redactingObjectEncoder masks values written to the wrapped ObjectEncoder.
+type redactingObjectEncoder struct {
+	zapcore.ObjectEncoder
+	r *redactor
+}
+
+// masked writes the mask instead of the value if key is sensitive.
+func (e *redactingObjectEncoder) masked(key string) bool {
+	if !e.r.redactsKey(key) {
+		return false
+	}
+
+	e.ObjectEncoder.AddString(key, e.r.mask)
+
+	return true
+}
+
+func (e *redactingObjectEncoder) AddString(key, value string) {
+	if !e.masked(key) {
+		e.ObjectEncoder.AddString(key, e.r.redactString(value))
+	}
+}
+
+func (e *redactingObjectEncoder) AddByteString(key string, value []byte) {
+	e.AddString(key, string(value))
+}
+
+func (e *redactingObjectEncoder) AddBinary(key string, value []byte) {
+	if !e.masked(key) {
+		e.ObjectEncoder.AddBinary(key, value)
+	}
+}
+
+func (e *redactingObjectEncoder) AddBool(key string, value bool) {
+	if !e.masked(key) {
+		e.ObjectEncoder.AddBool(key, value)
+	}
+}
+
+func (e *redactingObjectEncoder) AddComplex128(key string, value complex128) {
+	if !e.masked(key) {
+		e.ObjectEncoder.AddComplex128(key, value)
+	}
+}
+
+func (e *redactingObjectEncoder) AddComplex64(key string, value complex64) {
+	if !e.masked(key) {
+		e.ObjectEncoder.AddComplex64(key, value)
+	}
+}
+
+func (e *redactingObjectEncoder) AddDuration(key string, value time.Duration) {
+	if !e.masked(key) {
+		e.ObjectEncoder.AddDuration(key, value)
+	}
+}
+
+func (e *redactingObjectEncoder) AddFloat64(key string, value float64) {
+	if !e.masked(key) {
+		e.ObjectEncoder.AddFloat64(key, value)
+	}
+}
+
+func (e *redactingObjectEncoder) AddFloat32(key string, value float32) {
+	if !e.masked(key) {
+		e.ObjectEncoder.AddFloat32(key, value)
+	}
+}
+
+func (e *redactingObjectEncoder) AddInt(key string, value int) {
+	e.AddInt64(key, int64(value))
+}
+
+func (e *redactingObjectEncoder) AddInt64(key string, value int64) {
+	if !e.masked(key) {
+		e.ObjectEncoder.AddInt64(key, value)
+	}
+}
+
+func (e *redactingObjectEncoder) AddInt32(key string, value int32) {
+	if !e.masked(key) {
+		e.ObjectEncoder.AddInt32(key, value)
+	}
+}
+
+func (e *redactingObjectEncoder) AddInt16(key string, value int16) {
+	if !e.masked(key) {
+		e.ObjectEncoder.AddInt16(key, value)
+	}
+}
+
+func (e *redactingObjectEncoder) AddInt8(key string, value int8) {
+	if !e.masked(key) {
+		e.ObjectEncoder.AddInt8(key, value)
+	}
+}
+
+func (e *redactingObjectEncoder) AddTime(key string, value time.Time) {
+	if !e.masked(key) {
+		e.ObjectEncoder.AddTime(key, value)
+	}
+}
+
+func (e *redactingObjectEncoder) AddUint(key string, value uint) {
+	e.AddUint64(key, uint64(value))
+}
+
+func (e *redactingObjectEncoder) AddUint64(key string, value uint64) {
+	if !e.masked(key) {
+		e.ObjectEncoder.AddUint64(key, value)
+	}
+}
+
+func (e *redactingObjectEncoder) AddUint32(key string, value uint32) {
+	if !e.masked(key) {
+		e.ObjectEncoder.AddUint32(key, value)
+	}
+}
+
+func (e *redactingObjectEncoder) AddUint16(key string, value uint16) {
+	if !e.masked(key) {
+		e.ObjectEncoder.AddUint16(key, value)
+	}
+}
+
+func (e *redactingObjectEncoder) AddUint8(key string, value uint8) {
+	if !e.masked(key) {
+		e.ObjectEncoder.AddUint8(key, value)
+	}
+}
+
+func (e *redactingObjectEncoder) AddUintptr(key string, value uintptr) {
+	if !e.masked(key) {
+		e.ObjectEncoder.AddUintptr(key, value)
+	}
+}
+
+func (e *redactingObjectEncoder) AddReflected(key string, value any) error {
+	if e.masked(key) {
+		return nil
+	}
+
+	return e.ObjectEncoder.AddReflected(key, e.r.sanitize(value))
+}
+
+func (e *redactingObjectEncoder) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	if e.masked(key) {
+		return nil
+	}
+
+	return e.ObjectEncoder.AddObject(key, redactingObjectMarshaler{marshaler, e.r})
+}
+
+func (e *redactingObjectEncoder) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	if e.masked(key) {
+		return nil
+	}
+
+	return e.ObjectEncoder.AddArray(key, redactingArrayMarshaler{marshaler, e.r})
+}
+
+type redactingObjectMarshaler struct {
+	marshaler zapcore.ObjectMarshaler
+	r         *redactor
+}
+
+func (m redactingObjectMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return m.marshaler.MarshalLogObject(&redactingObjectEncoder{ObjectEncoder: enc, r: m.r})
+}
+
+type redactingArrayMarshaler struct {
+	marshaler zapcore.ArrayMarshaler
+	r         *redactor
+}
+
+func (m redactingArrayMarshaler) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	return m.marshaler.MarshalLogArray(&redactingArrayEncoder{ArrayEncoder: enc, r: m.r})
+}
+
+// redactingArrayEncoder masks values written to the wrapped ArrayEncoder.
+type redactingArrayEncoder struct {
+	zapcore.ArrayEncoder
+	r *redactor
+}
+
+func (e *redactingArrayEncoder) AppendString(value string) {
+	e.ArrayEncoder.AppendString(e.r.redactString(value))
+}
+
+func (e *redactingArrayEncoder) AppendByteString(value []byte) {
+	e.AppendString(string(value))
+}
+
+func (e *redactingArrayEncoder) AppendReflected(value any) error {
+	return e.ArrayEncoder.AppendReflected(e.r.sanitize(value))
+}
+
+func (e *redactingArrayEncoder) AppendObject(marshaler zapcore.ObjectMarshaler) error {
+	return e.ArrayEncoder.AppendObject(redactingObjectMarshaler{marshaler, e.r})
+}
+
+func (e *redactingArrayEncoder) AppendArray(marshaler zapcore.ArrayMarshaler) error {
+	return e.ArrayEncoder.AppendArray(redactingArrayMarshaler{marshaler, e.r})
+}
+
+// redactingEncoder routes context fields added by With and the fields of
+// each entry through redactingObjectEncoder before the wrapped encoder sees
+// them.
+type redactingEncoder struct {
+	*redactingObjectEncoder
+	enc zapcore.Encoder
+}
+
+func newRedactingEncoder(enc zapcore.Encoder, r *redactor) *redactingEncoder {
+	return &redactingEncoder{
+		redactingObjectEncoder: &redactingObjectEncoder{ObjectEncoder: enc, r: r},
+		enc:                    enc,
+	}
+}
+
+func (e *redactingEncoder) Clone() zapcore.Encoder {
+	return newRedactingEncoder(e.enc.Clone(), e.r)
+}
+
+func (e *redactingEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	clone := newRedactingEncoder(e.enc.Clone(), e.r)
+	for _, field := range fields {
+		field.AddTo(clone)
+	}
+
+	ent.Message = e.r.redactString(ent.Message)
+
+	return clone.enc.EncodeEntry(ent, nil)
+}
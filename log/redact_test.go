@@ -0,0 +1,115 @@
+package log_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ranefattesingh/pkg/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type credentials struct {
+	User    string `json:"user"`
+	Pin     string `json:"pin" log:"redact"`
+	Comment string `json:"comment"`
+}
+
+func TestRedactingEncoder(t *testing.T) {
+	t.Parallel()
+
+	testTable := map[string]struct {
+		message  string
+		fields   []zap.Field
+		leaked   string
+		expected string
+	}{
+		"should mask sensitive keys": {
+			"login", []zap.Field{zap.String("db_password", "hunter2")}, "hunter2", `"db_password":"[REDACTED]"`,
+		},
+		"should mask sensitive numeric keys": {
+			"login", []zap.Field{zap.Int("secret", 1234)}, "1234", `"secret":"[REDACTED]"`,
+		},
+		"should mask sensitive keys of every numeric type": {
+			"login", []zap.Field{
+				zap.Int32("secret", 1111), zap.Uint32("token", 2222), zap.Float64("password", 3333), zap.Int8("pin_secret", 44),
+			}, "", `"secret":"[REDACTED]","token":"[REDACTED]","password":"[REDACTED]","pin_secret":"[REDACTED]"`,
+		},
+		"should mask sensitive keys of other types": {
+			"login", []zap.Field{
+				zap.Bool("secret", true), zap.Duration("token", time.Second), zap.Time("password", time.Unix(0, 0)),
+			}, "", `"secret":"[REDACTED]","token":"[REDACTED]","password":"[REDACTED]"`,
+		},
+		"should mask keys whose segments match": {
+			"login", []zap.Field{zap.String("apiToken", "t0k3n"), zap.String("X-Auth-Token", "h3ad3r")}, "", `"apiToken":"[REDACTED]","X-Auth-Token":"[REDACTED]"`,
+		},
+		"should not mask keys only containing a field": {
+			"completion", []zap.Field{zap.Int("max_tokens", 512)}, "", `"max_tokens":512`,
+		},
+		"should not mask digits failing the luhn check": {
+			"order", []zap.Field{zap.String("order_id", "1700000000123456789")}, "", `"order_id":"1700000000123456789"`,
+		},
+		"should mask bearer tokens in values": {
+			"request", []zap.Field{zap.String("header", "Bearer abc.def.ghi")}, "abc.def", `"header":"[REDACTED]"`,
+		},
+		"should mask emails in messages": {
+			"sent mail to jane@example.com", nil, "jane@example.com", `"message":"sent mail to [REDACTED]"`,
+		},
+		"should mask card numbers in errors": {
+			"payment", []zap.Field{zap.Error(errors.New("declined 4111 1111 1111 1111"))}, "4111", `"error":"declined [REDACTED]"`,
+		},
+		"should mask tagged struct fields": {
+			"user", []zap.Field{zap.Any("credentials", credentials{User: "jane", Pin: "0000", Comment: "ok"})}, "0000", `"pin":"[REDACTED]"`,
+		},
+		"should mask sensitive map keys": {
+			"headers", []zap.Field{zap.Any("headers", map[string]string{"Authorization": "Basic xyz"})}, "xyz", `"Authorization":"[REDACTED]"`,
+		},
+		"should mask nested objects": {
+			"nested", []zap.Field{zap.Dict("user", zap.String("token", "t0k3n"))}, "t0k3n", `"token":"[REDACTED]"`,
+		},
+		"should mask strings in arrays": {
+			"recipients", []zap.Field{zap.Strings("to", []string{"jane@example.com"})}, "jane@example.com", `"to":["[REDACTED]"]`,
+		},
+	}
+
+	for scenario, tc := range testTable {
+		t.Run(scenario, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			encoder := log.NewRedactingEncoder(log.DefaultJSONEncoder(), log.DefaultRedactionConfig())
+			logger := zap.New(zapcore.NewCore(encoder, zapcore.AddSync(&buf), zapcore.DebugLevel))
+
+			logger.Info(tc.message, tc.fields...)
+
+			output := buf.String()
+			if tc.leaked != "" && strings.Contains(output, tc.leaked) {
+				t.Errorf("output %s leaked %q", output, tc.leaked)
+			}
+
+			if !strings.Contains(output, tc.expected) {
+				t.Errorf("output %s, expected to contain %s", output, tc.expected)
+			}
+		})
+	}
+}
+
+func TestRedactingEncoderWith(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	encoder := log.NewRedactingEncoder(log.DefaultJSONEncoder(), log.DefaultRedactionConfig())
+	logger := zap.New(zapcore.NewCore(encoder, zapcore.AddSync(&buf), zapcore.DebugLevel)).
+		With(zap.String("api_token", "t0k3n"))
+
+	logger.Info("request")
+
+	if strings.Contains(buf.String(), "t0k3n") {
+		t.Errorf("output %s leaked context field", buf.String())
+	}
+}
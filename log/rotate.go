@@ -0,0 +1,411 @@
+package log
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	backupTimeFormat = "2006-01-02T15-04-05.000000000"
+	// backupTimeLayout parses backup names; the fractional seconds are
+	// accepted without being part of the layout.
+	backupTimeLayout = "2006-01-02T15-04-05"
+)
+
+var ErrFilenameRequired = errors.New("rotating file requires a filename")
+
+// FileConfig configures a RotatingFile.
+type FileConfig struct {
+	Filename string
+	// MaxSize is the size in bytes after which the file is rotated. Zero
+	// disables size based rotation.
+	MaxSize int64
+	// MaxAge is how long a file is written to before it is rotated, counted
+	// from the previous rotation, or from the last modification of a file
+	// without backups, so restarts do not reset it. Zero disables age based
+	// rotation.
+	MaxAge time.Duration
+	// MaxBackups is the number of rotated files to keep. Zero keeps all.
+	MaxBackups int
+	// Compress gzips rotated files.
+	Compress bool
+}
+
+// RotatingFile is an io.Writer that writes to Filename and rotates it by size
+// and/or age. The file is reopened on SIGHUP so that it also works with an
+// external logrotate.
+type RotatingFile struct {
+	config FileConfig
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	// startedAt is when writing to the file began, for age based rotation.
+	startedAt time.Time
+
+	millMu sync.Mutex
+	millWg sync.WaitGroup
+
+	sigChan chan os.Signal
+	done    chan struct{}
+}
+
+func NewRotatingFile(c FileConfig) (*RotatingFile, error) {
+	if c.Filename == "" {
+		return nil, ErrFilenameRequired
+	}
+
+	rf := &RotatingFile{
+		config:  c,
+		sigChan: make(chan os.Signal, 1),
+		done:    make(chan struct{}),
+	}
+
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+
+	signal.Notify(rf.sigChan, syscall.SIGHUP)
+
+	go rf.reopenOnSignal()
+
+	return rf, nil
+}
+
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	select {
+	case <-rf.done:
+		return 0, os.ErrClosed
+	default:
+	}
+
+	if rf.file == nil {
+		if err := rf.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if rf.shouldRotate(int64(len(p))) {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+
+	return n, err
+}
+
+func (rf *RotatingFile) Sync() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.file == nil {
+		return nil
+	}
+
+	return rf.file.Sync()
+}
+
+// Reopen closes the current file and opens Filename again.
+func (rf *RotatingFile) Reopen() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if err := rf.close(); err != nil {
+		return err
+	}
+
+	return rf.open()
+}
+
+// Close closes the file and waits for pending compression of rotated files.
+func (rf *RotatingFile) Close() error {
+	signal.Stop(rf.sigChan)
+
+	rf.mu.Lock()
+	select {
+	case <-rf.done:
+	default:
+		close(rf.done)
+	}
+
+	err := rf.close()
+	rf.mu.Unlock()
+
+	rf.millWg.Wait()
+
+	return err
+}
+
+func (rf *RotatingFile) reopenOnSignal() {
+	for {
+		select {
+		case <-rf.sigChan:
+			_ = rf.Reopen()
+		case <-rf.done:
+			return
+		}
+	}
+}
+
+func (rf *RotatingFile) shouldRotate(writeSize int64) bool {
+	if rf.config.MaxSize > 0 && rf.size > 0 && rf.size+writeSize > rf.config.MaxSize {
+		return true
+	}
+
+	if rf.config.MaxAge > 0 && time.Since(rf.startedAt) >= rf.config.MaxAge {
+		return true
+	}
+
+	return false
+}
+
+func (rf *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(rf.config.Filename), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(rf.config.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+
+		return err
+	}
+
+	rf.file = file
+	rf.size = info.Size()
+	rf.startedAt = rf.fileStart(info)
+
+	return nil
+}
+
+// fileStart returns when writing to the open file began: the time of the
+// newest backup, which is when the file replaced it, or for a file that was
+// never rotated its modification time if it has content.
+func (rf *RotatingFile) fileStart(info os.FileInfo) time.Time {
+	now := time.Now()
+
+	if info.Size() == 0 {
+		return now
+	}
+
+	start := info.ModTime()
+
+	if backups, err := rf.backupFiles(); err == nil && len(backups) > 0 {
+		start = backups[0].time
+	}
+
+	if start.After(now) {
+		return now
+	}
+
+	return start
+}
+
+func (rf *RotatingFile) close() error {
+	if rf.file == nil {
+		return nil
+	}
+
+	err := rf.file.Close()
+	rf.file = nil
+
+	return err
+}
+
+func (rf *RotatingFile) rotate() error {
+	if err := rf.close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(rf.config.Filename, rf.nextBackupName(time.Now())); err != nil {
+		return err
+	}
+
+	if err := rf.open(); err != nil {
+		return err
+	}
+
+	rf.millWg.Add(1)
+
+	go func() {
+		defer rf.millWg.Done()
+
+		rf.mill()
+	}()
+
+	return nil
+}
+
+// nextBackupName returns a backup name for t that is not taken yet, so a
+// rename never replaces an earlier backup when the clock is too coarse to
+// tell rotations apart.
+func (rf *RotatingFile) nextBackupName(t time.Time) string {
+	for {
+		name := rf.backupName(t)
+
+		if !fileExists(name) && !fileExists(name+".gz") {
+			return name
+		}
+
+		t = t.Add(time.Nanosecond)
+	}
+}
+
+func fileExists(name string) bool {
+	_, err := os.Lstat(name)
+
+	return err == nil
+}
+
+func (rf *RotatingFile) backupName(t time.Time) string {
+	prefix, ext := rf.prefixAndExt()
+
+	return prefix + t.Format(backupTimeFormat) + ext
+}
+
+func (rf *RotatingFile) prefixAndExt() (string, string) {
+	ext := filepath.Ext(rf.config.Filename)
+
+	return strings.TrimSuffix(rf.config.Filename, ext) + "-", ext
+}
+
+// mill compresses rotated files and removes the ones exceeding MaxBackups.
+func (rf *RotatingFile) mill() {
+	rf.millMu.Lock()
+	defer rf.millMu.Unlock()
+
+	backups, err := rf.backups()
+	if err != nil {
+		return
+	}
+
+	if rf.config.MaxBackups > 0 && len(backups) > rf.config.MaxBackups {
+		for _, backup := range backups[rf.config.MaxBackups:] {
+			_ = os.Remove(backup)
+		}
+
+		backups = backups[:rf.config.MaxBackups]
+	}
+
+	if !rf.config.Compress {
+		return
+	}
+
+	for _, backup := range backups {
+		if strings.HasSuffix(backup, ".gz") {
+			continue
+		}
+
+		if err := compressFile(backup); err == nil {
+			_ = os.Remove(backup)
+		}
+	}
+}
+
+type backupFile struct {
+	name string
+	time time.Time
+}
+
+// backups returns the names of the rotated files, newest first.
+func (rf *RotatingFile) backups() ([]string, error) {
+	found, err := rf.backupFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(found))
+	for _, b := range found {
+		names = append(names, b.name)
+	}
+
+	return names, nil
+}
+
+// backupFiles returns the rotated files with the time they were rotated,
+// newest first.
+func (rf *RotatingFile) backupFiles() ([]backupFile, error) {
+	prefix, ext := rf.prefixAndExt()
+
+	entries, err := os.ReadDir(filepath.Dir(rf.config.Filename))
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]backupFile, 0)
+	basePrefix := filepath.Base(prefix)
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, basePrefix) {
+			continue
+		}
+
+		stamp := strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ext)
+		stamp = strings.TrimPrefix(stamp, basePrefix)
+
+		// Names are formatted in local time.
+		t, err := time.ParseInLocation(backupTimeLayout, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+
+		found = append(found, backupFile{name: filepath.Join(filepath.Dir(rf.config.Filename), name), time: t})
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].time.After(found[j].time)
+	})
+
+	return found, nil
+}
+
+func compressFile(name string) (err error) {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if closeErr := dst.Close(); err == nil {
+			err = closeErr
+		}
+
+		if err != nil {
+			_ = os.Remove(name + ".gz")
+		}
+	}()
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
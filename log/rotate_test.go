@@ -0,0 +1,257 @@
+package log_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ranefattesingh/pkg/log"
+)
+
+func TestRotatingFile(t *testing.T) {
+	t.Parallel()
+
+	testTable := map[string]struct {
+		config          log.FileConfig
+		writes          int
+		expectedBackups int
+		expectedSuffix  string
+	}{
+		"should rotate by size":              {log.FileConfig{MaxSize: 10}, 3, 2, ".log"},
+		"should keep max backups":            {log.FileConfig{MaxSize: 10, MaxBackups: 1}, 4, 1, ".log"},
+		"should compress rotated files":      {log.FileConfig{MaxSize: 10, Compress: true}, 3, 2, ".log.gz"},
+		"should not rotate below size limit": {log.FileConfig{MaxSize: 100}, 3, 0, ""},
+	}
+
+	for scenario, tc := range testTable {
+		t.Run(scenario, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			tc.config.Filename = filepath.Join(dir, "app.log")
+
+			rf, err := log.NewRotatingFile(tc.config)
+			if err != nil {
+				t.Fatalf("NewRotatingFile() err = %v", err)
+			}
+
+			for i := 0; i < tc.writes; i++ {
+				if _, err := rf.Write([]byte("0123456789\n")); err != nil {
+					t.Fatalf("Write() err = %v", err)
+				}
+			}
+
+			if err := rf.Close(); err != nil {
+				t.Fatalf("Close() err = %v", err)
+			}
+
+			backups, err := filepath.Glob(filepath.Join(dir, "app-*"))
+			if err != nil {
+				t.Fatalf("Glob() err = %v", err)
+			}
+
+			if len(backups) != tc.expectedBackups {
+				t.Fatalf("backups = %v, expected %d", backups, tc.expectedBackups)
+			}
+
+			for _, backup := range backups {
+				if !strings.HasSuffix(backup, tc.expectedSuffix) {
+					t.Errorf("backup %q, expected suffix %q", backup, tc.expectedSuffix)
+				}
+			}
+
+			if _, err := os.Stat(tc.config.Filename); err != nil {
+				t.Errorf("active file err = %v", err)
+			}
+		})
+	}
+}
+
+func TestRotatingFileReopen(t *testing.T) {
+	t.Parallel()
+
+	filename := filepath.Join(t.TempDir(), "app.log")
+
+	rf, err := log.NewRotatingFile(log.FileConfig{Filename: filename})
+	if err != nil {
+		t.Fatalf("NewRotatingFile() err = %v", err)
+	}
+	defer rf.Close()
+
+	if err := os.Rename(filename, filename+".1"); err != nil {
+		t.Fatalf("Rename() err = %v", err)
+	}
+
+	if err := rf.Reopen(); err != nil {
+		t.Fatalf("Reopen() err = %v", err)
+	}
+
+	if _, err := rf.Write([]byte("after reopen\n")); err != nil {
+		t.Fatalf("Write() err = %v", err)
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("ReadFile() err = %v", err)
+	}
+
+	if string(content) != "after reopen\n" {
+		t.Errorf("content = %q, expected %q", content, "after reopen\n")
+	}
+}
+
+func TestRotatingFileKeepsEveryRotation(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	rf, err := log.NewRotatingFile(log.FileConfig{Filename: filepath.Join(dir, "app.log"), MaxSize: 20})
+	if err != nil {
+		t.Fatalf("NewRotatingFile() err = %v", err)
+	}
+
+	const writes = 200
+
+	// Rotations follow each other faster than the backup timestamp changes.
+	for i := 0; i < writes; i++ {
+		if _, err := rf.Write([]byte("0123456789\n")); err != nil {
+			t.Fatalf("Write() err = %v", err)
+		}
+	}
+
+	if err := rf.Close(); err != nil {
+		t.Fatalf("Close() err = %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "app*"))
+	if err != nil {
+		t.Fatalf("Glob() err = %v", err)
+	}
+
+	lines := 0
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("ReadFile() err = %v", err)
+		}
+
+		lines += strings.Count(string(content), "\n")
+	}
+
+	if lines != writes {
+		t.Errorf("lines = %d in %d files, expected %d", lines, len(files), writes)
+	}
+}
+
+func TestRotatingFileMaxAge(t *testing.T) {
+	t.Parallel()
+
+	old := time.Now().Add(-2 * time.Hour)
+
+	testTable := map[string]struct {
+		setup           func(t *testing.T, filename string)
+		expectedBackups int
+	}{
+		"should not rotate a new file": {
+			setup:           func(t *testing.T, filename string) {},
+			expectedBackups: 0,
+		},
+		"should rotate a file older than max age": {
+			setup: func(t *testing.T, filename string) {
+				writeFile(t, filename, "old\n")
+
+				if err := os.Chtimes(filename, old, old); err != nil {
+					t.Fatalf("Chtimes() err = %v", err)
+				}
+			},
+			expectedBackups: 1,
+		},
+		"should count age from the previous rotation across restarts": {
+			setup: func(t *testing.T, filename string) {
+				// Modified just now by the previous process.
+				writeFile(t, filename, "recent\n")
+				writeFile(t, strings.TrimSuffix(filename, ".log")+"-"+old.Format("2006-01-02T15-04-05.000000000")+".log", "older\n")
+			},
+			expectedBackups: 2,
+		},
+		"should not rotate a recent file after a recent rotation": {
+			setup: func(t *testing.T, filename string) {
+				writeFile(t, filename, "recent\n")
+				writeFile(t, strings.TrimSuffix(filename, ".log")+"-"+time.Now().Format("2006-01-02T15-04-05.000000000")+".log", "older\n")
+			},
+			expectedBackups: 1,
+		},
+	}
+
+	for scenario, tc := range testTable {
+		t.Run(scenario, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			filename := filepath.Join(dir, "app.log")
+			tc.setup(t, filename)
+
+			rf, err := log.NewRotatingFile(log.FileConfig{Filename: filename, MaxAge: time.Hour})
+			if err != nil {
+				t.Fatalf("NewRotatingFile() err = %v", err)
+			}
+
+			if _, err := rf.Write([]byte("entry\n")); err != nil {
+				t.Fatalf("Write() err = %v", err)
+			}
+
+			if err := rf.Close(); err != nil {
+				t.Fatalf("Close() err = %v", err)
+			}
+
+			backups, err := filepath.Glob(filepath.Join(dir, "app-*"))
+			if err != nil {
+				t.Fatalf("Glob() err = %v", err)
+			}
+
+			if len(backups) != tc.expectedBackups {
+				t.Errorf("backups = %v, expected %d", backups, tc.expectedBackups)
+			}
+		})
+	}
+}
+
+func TestRotatingFileMaxAgeWhileWriting(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	rf, err := log.NewRotatingFile(log.FileConfig{Filename: filepath.Join(dir, "app.log"), MaxAge: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewRotatingFile() err = %v", err)
+	}
+	defer rf.Close()
+
+	for i := 0; i < 2; i++ {
+		if _, err := rf.Write([]byte("entry\n")); err != nil {
+			t.Fatalf("Write() err = %v", err)
+		}
+
+		time.Sleep(60 * time.Millisecond)
+	}
+
+	backups, err := filepath.Glob(filepath.Join(dir, "app-*"))
+	if err != nil {
+		t.Fatalf("Glob() err = %v", err)
+	}
+
+	if len(backups) != 1 {
+		t.Errorf("backups = %v, expected 1", backups)
+	}
+}
+
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() err = %v", err)
+	}
+}
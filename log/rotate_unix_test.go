@@ -0,0 +1,54 @@
+//go:build unix
+
+package log_test
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ranefattesingh/pkg/log"
+)
+
+func TestRotatingFileReopenOnSIGHUP(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.log")
+
+	rf, err := log.NewRotatingFile(log.FileConfig{Filename: filename})
+	if err != nil {
+		t.Fatalf("NewRotatingFile() err = %v", err)
+	}
+	defer rf.Close()
+
+	// As logrotate does before signalling the process.
+	if err := os.Rename(filename, filename+".1"); err != nil {
+		t.Fatalf("Rename() err = %v", err)
+	}
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("Kill() err = %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for _, err := os.Stat(filename); err != nil; _, err = os.Stat(filename) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Stat() err = %v, expected the file to be reopened", err)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := rf.Write([]byte("after reopen\n")); err != nil {
+		t.Fatalf("Write() err = %v", err)
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("ReadFile() err = %v", err)
+	}
+
+	if string(content) != "after reopen\n" {
+		t.Errorf("content = %q, expected %q", content, "after reopen\n")
+	}
+}
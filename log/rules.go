@@ -0,0 +1,145 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const fallbackRuleName = "*"
+
+var ErrInvalidLevelRule = errors.New("invalid level rule")
+
+type levelRule struct {
+	name  string
+	level zapcore.Level
+}
+
+// levelRules decides the level of an entry from the name of its logger. A
+// rule for "db" applies to the logger named "db" and to its children such as
+// "db.pool"; the longest matching name wins. Loggers without a matching rule
+// use fallback, the level changed by SetLevel.
+type levelRules struct {
+	fallback zap.AtomicLevel
+	rules    atomic.Pointer[[]levelRule]
+}
+
+func newLevelRules(fallback zap.AtomicLevel) *levelRules {
+	r := &levelRules{fallback: fallback}
+	r.rules.Store(&[]levelRule{})
+
+	return r
+}
+
+// set replaces the named rules with those in s, e.g.
+// "db=debug,http=warn,*=info". A "*" rule changes the fallback level.
+func (r *levelRules) set(s string) error {
+	rules := make([]levelRule, 0)
+
+	var fallback *zapcore.Level
+
+	for _, rule := range strings.Split(s, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		name, l, ok := strings.Cut(rule, "=")
+		name = strings.TrimSpace(name)
+
+		if !ok || name == "" {
+			return fmt.Errorf("%w: %q", ErrInvalidLevelRule, rule)
+		}
+
+		level, err := parseLevel(LogLevel(strings.TrimSpace(l)))
+		if err != nil {
+			return fmt.Errorf("%w: %q: %w", ErrInvalidLevelRule, rule, err)
+		}
+
+		if name == fallbackRuleName {
+			fallback = &level
+
+			continue
+		}
+
+		rules = append(rules, levelRule{name: name, level: level})
+	}
+
+	sort.SliceStable(rules, func(i, j int) bool {
+		return len(rules[i].name) > len(rules[j].name)
+	})
+
+	if fallback != nil {
+		r.fallback.SetLevel(*fallback)
+	}
+
+	r.rules.Store(&rules)
+
+	return nil
+}
+
+func (r *levelRules) String() string {
+	rules := *r.rules.Load()
+
+	parts := make([]string, 0, len(rules)+1)
+	for _, rule := range rules {
+		parts = append(parts, rule.name+"="+rule.level.String())
+	}
+
+	sort.Strings(parts)
+
+	return strings.Join(append(parts, fallbackRuleName+"="+r.fallback.Level().String()), ",")
+}
+
+func (r *levelRules) levelFor(name string) zapcore.Level {
+	for _, rule := range *r.rules.Load() {
+		if name == rule.name || strings.HasPrefix(name, rule.name+".") {
+			return rule.level
+		}
+	}
+
+	return r.fallback.Level()
+}
+
+// Enabled reports whether l is enabled for any logger name.
+func (r *levelRules) Enabled(l zapcore.Level) bool {
+	if r.fallback.Enabled(l) {
+		return true
+	}
+
+	for _, rule := range *r.rules.Load() {
+		if l >= rule.level {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ruleCore drops entries below the level of their logger name before they
+// reach the wrapped core.
+type ruleCore struct {
+	zapcore.Core
+	rules *levelRules
+}
+
+func (c ruleCore) Enabled(l zapcore.Level) bool {
+	return c.rules.Enabled(l) && c.Core.Enabled(l)
+}
+
+func (c ruleCore) With(fields []zapcore.Field) zapcore.Core {
+	return ruleCore{Core: c.Core.With(fields), rules: c.rules}
+}
+
+func (c ruleCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level < c.rules.levelFor(ent.LoggerName) {
+		return ce
+	}
+
+	return c.Core.Check(ent, ce)
+}
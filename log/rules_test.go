@@ -0,0 +1,73 @@
+package log_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ranefattesingh/pkg/log"
+)
+
+func TestLevelRules(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	instance, err := log.New(log.Config{
+		Output:     &buf,
+		LogLevel:   log.ErrorLevel,
+		LevelRules: "db=debug, http=warn, *=info",
+	})
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+
+	logger := instance.Logger()
+	logger.Named("db").Named("pool").Debug("db debug")
+	logger.Named("http").Info("http info")
+	logger.Named("http").Warn("http warn")
+	logger.Named("worker").Info("worker info")
+	logger.Named("worker").Debug("worker debug")
+
+	assertMessages(t, &buf, []string{"db debug", "http warn", "worker info"})
+
+	if rules := instance.LevelRules(); rules != "db=debug,http=warn,*=info" {
+		t.Errorf("LevelRules() = %q", rules)
+	}
+
+	if err := instance.SetLevelRules("http=debug,*=error"); err != nil {
+		t.Fatalf("SetLevelRules() err = %v", err)
+	}
+
+	logger.Named("db").Debug("db debug")
+	logger.Named("http").Debug("http debug")
+	logger.Named("worker").Warn("worker warn")
+
+	assertMessages(t, &buf, []string{"http debug"})
+
+	if err := instance.SetLevelRules("db"); !errors.Is(err, log.ErrInvalidLevelRule) {
+		t.Errorf("SetLevelRules() err = %v, expected %v", err, log.ErrInvalidLevelRule)
+	}
+
+	if err := instance.SetLevelRules("db=verbose"); !errors.Is(err, log.ErrInvalidLevelRule) {
+		t.Errorf("SetLevelRules() err = %v, expected %v", err, log.ErrInvalidLevelRule)
+	}
+}
+
+func assertMessages(t *testing.T, buf *bytes.Buffer, expected []string) {
+	t.Helper()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	buf.Reset()
+
+	if len(lines) != len(expected) {
+		t.Fatalf("logged %d entries, expected %d: %v", len(lines), len(expected), lines)
+	}
+
+	for i, message := range expected {
+		if !strings.Contains(lines[i], `"message":"`+message+`"`) {
+			t.Errorf("entry %d = %s, expected message %q", i, lines[i], message)
+		}
+	}
+}
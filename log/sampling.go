@@ -0,0 +1,209 @@
+package log
+
+import (
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultSamplingInitial    = 100
+	defaultSamplingThereafter = 100
+)
+
+// SamplingConfig configures zap sampling. Within each Tick the first Initial
+// entries with the same level and message are logged, after that every
+// Thereafter-th entry is logged. Zero values use a Tick of one second and
+// 100 for Initial and Thereafter.
+type SamplingConfig struct {
+	Tick       time.Duration
+	Initial    int
+	Thereafter int
+	// DropThereafter drops every entry after the first Initial within a Tick
+	// instead of logging every Thereafter-th.
+	DropThereafter bool
+}
+
+// RateLimitConfig limits entries with the same level, logger name and message
+// to Burst per Interval. When a throttled interval ends a summary entry
+// reporting the number of suppressed messages is written, at the latest when
+// the logger is closed.
+type RateLimitConfig struct {
+	Interval time.Duration
+	Burst    int
+}
+
+func newSampledCore(core zapcore.Core, c SamplingConfig) zapcore.Core {
+	tick := c.Tick
+	if tick <= 0 {
+		tick = time.Second
+	}
+
+	// zap drops every entry when both are zero.
+	initial := c.Initial
+	if initial <= 0 {
+		initial = defaultSamplingInitial
+	}
+
+	thereafter := c.Thereafter
+
+	switch {
+	case c.DropThereafter:
+		thereafter = 0
+	case thereafter <= 0:
+		thereafter = defaultSamplingThereafter
+	}
+
+	return zapcore.NewSamplerWithOptions(core, tick, initial, thereafter)
+}
+
+type rateLimitCore struct {
+	zapcore.Core
+	limiter *rateLimiter
+}
+
+// newRateLimitedCore returns a core rate limiting core and the limiter, to be
+// closed before the sinks of core.
+func newRateLimitedCore(core zapcore.Core, c RateLimitConfig) (zapcore.Core, io.Closer) {
+	interval := c.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	burst := c.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+
+	limiter := &rateLimiter{
+		interval: interval,
+		burst:    burst,
+		windows:  make(map[rateLimitKey]*rateLimitWindow),
+	}
+
+	return &rateLimitCore{Core: core, limiter: limiter}, limiter
+}
+
+func (c *rateLimitCore) With(fields []zapcore.Field) zapcore.Core {
+	return &rateLimitCore{
+		Core:    c.Core.With(fields),
+		limiter: c.limiter,
+	}
+}
+
+func (c *rateLimitCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+
+	if !c.limiter.allow(c.Core, ent) {
+		return ce
+	}
+
+	return c.Core.Check(ent, ce)
+}
+
+type rateLimitKey struct {
+	level      zapcore.Level
+	loggerName string
+	message    string
+}
+
+type rateLimitWindow struct {
+	count      int
+	suppressed int
+	core       zapcore.Core
+	timer      *time.Timer
+}
+
+type rateLimiter struct {
+	interval time.Duration
+	burst    int
+
+	mu      sync.Mutex
+	windows map[rateLimitKey]*rateLimitWindow
+	closed  bool
+}
+
+func (r *rateLimiter) allow(core zapcore.Core, ent zapcore.Entry) bool {
+	key := rateLimitKey{level: ent.Level, loggerName: ent.LoggerName, message: ent.Message}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return true
+	}
+
+	window, ok := r.windows[key]
+	if !ok {
+		window = &rateLimitWindow{}
+		r.windows[key] = window
+
+		// The window is closed by a timer so the summary is written even
+		// if the message is never logged again.
+		window.timer = time.AfterFunc(r.interval, func() {
+			r.closeWindow(key)
+		})
+	}
+
+	window.count++
+	if window.count <= r.burst {
+		return true
+	}
+
+	window.suppressed++
+	window.core = core
+
+	return false
+}
+
+func (r *rateLimiter) closeWindow(key rateLimitKey) {
+	r.mu.Lock()
+	window := r.windows[key]
+	delete(r.windows, key)
+	r.mu.Unlock()
+
+	writeSummary(key, window)
+}
+
+// Close stops the timers of open windows and writes their summaries, so no
+// summary is written to the sinks once they are closed.
+func (r *rateLimiter) Close() error {
+	r.mu.Lock()
+	windows := r.windows
+	r.windows = make(map[rateLimitKey]*rateLimitWindow)
+	r.closed = true
+	r.mu.Unlock()
+
+	for key, window := range windows {
+		window.timer.Stop()
+		writeSummary(key, window)
+	}
+
+	return nil
+}
+
+func writeSummary(key rateLimitKey, window *rateLimitWindow) {
+	if window == nil || window.suppressed == 0 {
+		return
+	}
+
+	summary := zapcore.Entry{
+		Level:      key.level,
+		Time:       time.Now(),
+		LoggerName: key.loggerName,
+		Message:    "suppressed " + strconv.Itoa(window.suppressed) + " similar messages",
+	}
+
+	if ce := window.core.Check(summary, nil); ce != nil {
+		ce.Write(
+			zap.String("suppressed_message", key.message),
+			zap.Int("suppressed_count", window.suppressed),
+		)
+	}
+}
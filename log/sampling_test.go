@@ -0,0 +1,170 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ranefattesingh/pkg/log"
+)
+
+// syncBuffer is written by the rate limiter timer while the test reads it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) entries(t *testing.T) []map[string]any {
+	t.Helper()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entries := make([]map[string]any, 0)
+
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		entry := map[string]any{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Unmarshal(%q) err = %v", line, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func TestSampling(t *testing.T) {
+	t.Parallel()
+
+	testTable := map[string]struct {
+		sampling        log.SamplingConfig
+		writes          int
+		expectedEntries int
+	}{
+		"should default a zero config":          {log.SamplingConfig{}, 5, 5},
+		"should log the first Initial entries":  {log.SamplingConfig{Tick: time.Hour, Initial: 2, Thereafter: 100}, 5, 2},
+		"should log every Thereafter-th entry":  {log.SamplingConfig{Tick: time.Hour, Initial: 1, Thereafter: 2}, 5, 3},
+		"should default Thereafter when unset":  {log.SamplingConfig{Tick: time.Hour, Initial: 2}, 5, 2},
+		"should drop every entry after Initial": {log.SamplingConfig{Tick: time.Hour, Initial: 1, Thereafter: 2, DropThereafter: true}, 5, 1},
+	}
+
+	for scenario, tc := range testTable {
+		t.Run(scenario, func(t *testing.T) {
+			t.Parallel()
+
+			var output syncBuffer
+
+			sampling := tc.sampling
+
+			instance, err := log.New(log.Config{Output: &output, Sampling: &sampling})
+			if err != nil {
+				t.Fatalf("New() err = %v, expected nil", err)
+			}
+
+			for i := 0; i < tc.writes; i++ {
+				instance.Logger().Info("sampled")
+			}
+
+			if entries := output.entries(t); len(entries) != tc.expectedEntries {
+				t.Errorf("entries = %d, expected %d", len(entries), tc.expectedEntries)
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	t.Parallel()
+
+	var output syncBuffer
+
+	instance, err := log.New(log.Config{
+		Output:    &output,
+		RateLimit: &log.RateLimitConfig{Interval: 50 * time.Millisecond, Burst: 2},
+	})
+	if err != nil {
+		t.Fatalf("New() err = %v, expected nil", err)
+	}
+
+	logger := instance.Logger().Named("db")
+	for i := 0; i < 5; i++ {
+		logger.Warn("connection lost")
+	}
+
+	logger.Warn("other message")
+
+	if entries := output.entries(t); len(entries) != 3 {
+		t.Fatalf("entries before the window closed = %d, expected 3", len(entries))
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(output.entries(t)) < 4 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	entries := output.entries(t)
+	if len(entries) != 4 {
+		t.Fatalf("entries = %d, expected 4 with the summary", len(entries))
+	}
+
+	summary := entries[3]
+
+	expected := map[string]any{
+		"message":            "suppressed 3 similar messages",
+		"level":              "warn",
+		"logger":             "db",
+		"suppressed_message": "connection lost",
+		"suppressed_count":   float64(3),
+	}
+
+	for key, value := range expected {
+		if summary[key] != value {
+			t.Errorf("summary %s = %v, expected %v", key, summary[key], value)
+		}
+	}
+}
+
+func TestRateLimitClose(t *testing.T) {
+	t.Parallel()
+
+	var output syncBuffer
+
+	instance, err := log.New(log.Config{
+		Output:    &output,
+		RateLimit: &log.RateLimitConfig{Interval: time.Hour, Burst: 1},
+	})
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		instance.Logger().Warn("connection lost")
+	}
+
+	if err := instance.Close(); err != nil {
+		t.Fatalf("Close() err = %v", err)
+	}
+
+	entries := output.entries(t)
+	if len(entries) != 2 {
+		t.Fatalf("entries = %d, expected 2 with the summary written on close", len(entries))
+	}
+
+	if message := entries[1]["message"]; message != "suppressed 2 similar messages" {
+		t.Errorf("summary message = %v, expected %q", message, "suppressed 2 similar messages")
+	}
+}
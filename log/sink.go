@@ -0,0 +1,146 @@
+package log
+
+import (
+	"io"
+	"os"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Sink is a single log destination with its own encoder and minimum level.
+type Sink struct {
+	// Output defaults to os.Stdout.
+	Output io.Writer
+	// Encoder defaults to the encoder selected by EncoderPreset.
+	Encoder Encoder
+	// EncoderPreset selects the encoder when Encoder is nil.
+	EncoderPreset EncoderPreset
+	// LogLevel is the minimum level written to this sink. Empty means every
+	// entry enabled by the logger level is written.
+	LogLevel LogLevel
+	// Syslog, when set, sends entries encoded by Encoder to a syslog server
+	// instead of Output.
+	Syslog *SyslogConfig
+	// Journal, when set, sends entries to the systemd journal instead of
+	// Output. Encoder is not used; fields are written as journal fields.
+	Journal *JournalConfig
+}
+
+// sinkOptions are the parts of Config applied to every sink.
+type sinkOptions struct {
+	level       zapcore.LevelEnabler
+	redactor    *redactor
+	async       *AsyncConfig
+	errorCauses bool
+}
+
+// newTeeCore returns a core writing to every sink, and the writers it opened
+// that have to be closed with the logger.
+func newTeeCore(sinks []Sink, opts sinkOptions) (zapcore.Core, []io.Closer, error) {
+	cores := make([]zapcore.Core, 0, len(sinks))
+	closers := make([]io.Closer, 0)
+
+	for _, sink := range sinks {
+		core, closer, err := newSinkCore(sink, opts)
+		if err != nil {
+			return nil, closers, err
+		}
+
+		cores = append(cores, core)
+
+		if closer != nil {
+			closers = append(closers, closer)
+		}
+	}
+
+	return zapcore.NewTee(cores...), closers, nil
+}
+
+func newSinkCore(s Sink, opts sinkOptions) (zapcore.Core, io.Closer, error) {
+	encoder := s.Encoder
+	if encoder == nil {
+		preset, err := presetEncoder(s.EncoderPreset)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		encoder = preset
+	}
+
+	errorFields, mapsErrors := encoder.(*errorFieldsEncoder)
+
+	if opts.redactor != nil {
+		encoder = newRedactingEncoder(encoder, opts.redactor)
+	}
+
+	var enabler zapcore.LevelEnabler = opts.level
+	if s.LogLevel != "" {
+		sinkLevel, err := parseLevel(s.LogLevel)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		enabler = sinkLevelEnabler{min: sinkLevel, level: opts.level}
+	}
+
+	var (
+		core   zapcore.Core
+		closer io.Closer
+	)
+
+	switch {
+	case s.Syslog != nil:
+		writer, err := newSyslogWriter(*s.Syslog)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		core = &syslogCore{LevelEnabler: enabler, encoder: encoder, writer: writer}
+		closer = writer
+
+	case s.Journal != nil:
+		writer, err := newJournalWriter(*s.Journal)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		core = &journalCore{LevelEnabler: enabler, writer: writer, redactor: opts.redactor}
+		closer = writer
+
+	default:
+		output := s.Output
+		if output == nil {
+			output = os.Stdout
+		}
+
+		if opts.async != nil {
+			asyncWriter := NewAsyncWriter(output, *opts.async)
+			output = asyncWriter
+			closer = asyncWriter
+		}
+
+		core = zapcore.NewCore(encoder, zapcore.AddSync(output), enabler)
+	}
+
+	if mapsErrors && s.Journal == nil {
+		core = errorFieldsCore{Core: core, typeKey: errorFields.typeKey}
+	}
+
+	// Outside errorFieldsCore, which turns error fields into strings.
+	if opts.errorCauses {
+		core = errorCausesCore{Core: core}
+	}
+
+	return core, closer, nil
+}
+
+// sinkLevelEnabler enables entries at or above min that are also enabled by
+// the logger level, so runtime level changes still apply to every sink.
+type sinkLevelEnabler struct {
+	min   zapcore.Level
+	level zapcore.LevelEnabler
+}
+
+func (s sinkLevelEnabler) Enabled(l zapcore.Level) bool {
+	return l >= s.min && s.level.Enabled(l)
+}
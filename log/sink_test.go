@@ -0,0 +1,50 @@
+package log_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ranefattesingh/pkg/log"
+)
+
+func TestSinkLevels(t *testing.T) {
+	t.Parallel()
+
+	var debugOutput, infoOutput bytes.Buffer
+
+	instance, err := log.New(log.Config{
+		LogLevel: log.DebugLevel,
+		Sinks: []log.Sink{
+			{Output: &debugOutput, LogLevel: log.DebugLevel},
+			{Output: &infoOutput, LogLevel: log.InfoLevel},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() err = %v, expected nil", err)
+	}
+
+	instance.Logger().Debug("debug entry")
+	instance.Logger().Info("info entry")
+
+	testTable := map[string]struct {
+		output      *bytes.Buffer
+		message     string
+		expectWrite bool
+	}{
+		"should write debug entries to the debug sink":    {&debugOutput, "debug entry", true},
+		"should write info entries to the debug sink":     {&debugOutput, "info entry", true},
+		"should not write debug entries to the info sink": {&infoOutput, "debug entry", false},
+		"should write info entries to the info sink":      {&infoOutput, "info entry", true},
+	}
+
+	for scenario, tc := range testTable {
+		t.Run(scenario, func(t *testing.T) {
+			t.Parallel()
+
+			if written := strings.Contains(tc.output.String(), tc.message); written != tc.expectWrite {
+				t.Errorf("output contains %q = %v, expected %v", tc.message, written, tc.expectWrite)
+			}
+		})
+	}
+}
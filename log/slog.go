@@ -0,0 +1,212 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SlogHandler returns an slog.Handler that writes to the core of the global
+// logger, so slog records share its encoder, level and fields. It follows
+// later calls to Init and ReplaceGlobals.
+func SlogHandler() slog.Handler {
+	return &slogHandler{core: globalCore{}}
+}
+
+// NewSlogHandler returns an slog.Handler that writes to the core of l.
+func NewSlogHandler(l *zap.Logger) slog.Handler {
+	return &slogHandler{core: l.Core(), name: l.Name()}
+}
+
+// Slog returns an *slog.Logger backed by the global logger, whichever logger
+// that is when a record is written.
+func Slog() *slog.Logger {
+	return slog.New(SlogHandler())
+}
+
+// RedirectStdLog routes the standard library log package and the default
+// slog logger to the global logger. It returns a function that restores
+// both.
+func RedirectStdLog() func() {
+	previous := slog.Default()
+	slog.SetDefault(Slog())
+
+	restoreStdLog := zap.RedirectStdLog(zap.New(globalCore{}, zap.WithCaller(true)))
+
+	return func() {
+		slog.SetDefault(previous)
+		restoreStdLog()
+	}
+}
+
+type slogHandler struct {
+	core zapcore.Core
+	name string
+	// groups opened by WithGroup that have no attributes yet. They are
+	// applied lazily so that empty groups are omitted, as slog requires.
+	groups []string
+}
+
+func (h *slogHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return h.core.Enabled(zapLevel(l))
+}
+
+func (h *slogHandler) Handle(ctx context.Context, record slog.Record) error {
+	entry := zapcore.Entry{
+		Level:      zapLevel(record.Level),
+		Time:       record.Time,
+		LoggerName: h.name,
+		Message:    record.Message,
+	}
+
+	if record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		entry.Caller = zapcore.EntryCaller{
+			Defined:  true,
+			PC:       frame.PC,
+			File:     frame.File,
+			Line:     frame.Line,
+			Function: frame.Function,
+		}
+	}
+
+	ce := h.core.Check(entry, nil)
+	if ce == nil {
+		return nil
+	}
+
+	fields := make([]zapcore.Field, 0, record.NumAttrs())
+	record.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, a)
+
+		return true
+	})
+
+	if len(fields) > 0 {
+		fields = append(namespaces(h.groups), fields...)
+	}
+
+	ce.Write(append(TraceFields(ctx), fields...)...)
+
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]zapcore.Field, 0, len(attrs))
+	for _, a := range attrs {
+		fields = appendAttr(fields, a)
+	}
+
+	if len(fields) == 0 {
+		return h
+	}
+
+	return &slogHandler{
+		core: h.core.With(append(namespaces(h.groups), fields...)),
+		name: h.name,
+	}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	groups := make([]string, 0, len(h.groups)+1)
+	groups = append(groups, h.groups...)
+
+	return &slogHandler{
+		core:   h.core,
+		name:   h.name,
+		groups: append(groups, name),
+	}
+}
+
+func namespaces(groups []string) []zapcore.Field {
+	fields := make([]zapcore.Field, 0, len(groups))
+	for _, group := range groups {
+		fields = append(fields, zap.Namespace(group))
+	}
+
+	return fields
+}
+
+func appendAttr(fields []zapcore.Field, a slog.Attr) []zapcore.Field {
+	value := a.Value.Resolve()
+
+	if value.Kind() == slog.KindGroup {
+		attrs := value.Group()
+		if len(attrs) == 0 {
+			return fields
+		}
+
+		// Groups without a key are inlined.
+		if a.Key == "" {
+			for _, attr := range attrs {
+				fields = appendAttr(fields, attr)
+			}
+
+			return fields
+		}
+
+		return append(fields, zap.Object(a.Key, slogGroup(attrs)))
+	}
+
+	if a.Key == "" {
+		return fields
+	}
+
+	switch value.Kind() {
+	case slog.KindBool:
+		return append(fields, zap.Bool(a.Key, value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(a.Key, value.Duration()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(a.Key, value.Float64()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(a.Key, value.Int64()))
+	case slog.KindString:
+		return append(fields, zap.String(a.Key, value.String()))
+	case slog.KindTime:
+		return append(fields, zap.Time(a.Key, value.Time()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(a.Key, value.Uint64()))
+	default:
+		if err, ok := value.Any().(error); ok {
+			return append(fields, zap.NamedError(a.Key, err))
+		}
+
+		return append(fields, zap.Any(a.Key, value.Any()))
+	}
+}
+
+type slogGroup []slog.Attr
+
+func (g slogGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	fields := make([]zapcore.Field, 0, len(g))
+	for _, a := range g {
+		fields = appendAttr(fields, a)
+	}
+
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+
+	return nil
+}
+
+func zapLevel(l slog.Level) zapcore.Level {
+	switch {
+	case l < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case l < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case l < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
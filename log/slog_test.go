@@ -0,0 +1,81 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	stdlog "log"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/slogtest"
+
+	"github.com/ranefattesingh/pkg/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSlogHandler(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	encoder := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+		TimeKey:     "time",
+		LevelKey:    "level",
+		MessageKey:  "msg",
+		EncodeLevel: zapcore.CapitalLevelEncoder,
+		EncodeTime:  zapcore.RFC3339NanoTimeEncoder,
+	})
+	logger := zap.New(zapcore.NewCore(encoder, zapcore.AddSync(&buf), zapcore.DebugLevel))
+
+	results := func(t *testing.T) map[string]any {
+		line := buf.Bytes()
+		buf.Reset()
+
+		result := map[string]any{}
+		if err := json.Unmarshal(line, &result); err != nil {
+			t.Fatalf("Unmarshal(%q) err = %v", line, err)
+		}
+
+		return result
+	}
+
+	slogtest.Run(t, func(t *testing.T) slog.Handler {
+		buf.Reset()
+
+		return log.NewSlogHandler(logger)
+	}, results)
+}
+
+func TestRedirectStdLogFollowsInit(t *testing.T) {
+	nop, err := log.NewFromCore(zapcore.NewNopCore(), log.InfoLevel)
+	if err != nil {
+		t.Fatalf("NewFromCore() err = %v", err)
+	}
+
+	defer log.ReplaceGlobals(nop)()
+
+	// Set up before the logger they write to exists.
+	slogger := log.Slog()
+	restore := log.RedirectStdLog()
+	defer restore()
+
+	var first, second bytes.Buffer
+
+	for _, output := range []*bytes.Buffer{&first, &second} {
+		if err := log.Init(log.Config{Output: output}); err != nil {
+			t.Fatalf("Init() err = %v, expected nil", err)
+		}
+
+		stdlog.Print("from std log")
+		slogger.Info("from slog")
+	}
+
+	for name, output := range map[string]*bytes.Buffer{"first": &first, "second": &second} {
+		for _, message := range []string{"from std log", "from slog"} {
+			if count := strings.Count(output.String(), message); count != 1 {
+				t.Errorf("%s logger has %q %d times, expected once:\n%s", name, message, count, output.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// FacilityUser is the default syslog facility.
+	FacilityUser = 1
+	// FacilityDaemon is the syslog facility for system daemons.
+	FacilityDaemon = 3
+	// FacilityLocal0 is the first of the local use facilities, local0 to
+	// local7 are FacilityLocal0 to FacilityLocal0+7.
+	FacilityLocal0 = 16
+
+	defaultSyslogSocket = "/dev/log"
+	syslogDialTimeout   = 5 * time.Second
+	syslogTimeFormat    = "2006-01-02T15:04:05.000000Z07:00"
+	syslogNilValue      = "-"
+)
+
+var ErrUnsupportedSyslogNetwork = errors.New("unsupported syslog network")
+
+// SyslogConfig configures a syslog sink writing RFC 5424 messages.
+type SyslogConfig struct {
+	// Network is one of "udp", "tcp", "unix" or "unixgram". When both Network
+	// and Address are empty the local syslog socket /dev/log is used.
+	Network string
+	Address string
+	// Facility defaults to FacilityUser.
+	Facility int
+	// AppName defaults to the name of the executable.
+	AppName string
+	// Hostname defaults to os.Hostname.
+	Hostname string
+}
+
+// syslogSeverity maps levels to RFC 5424 severities.
+func syslogSeverity(l zapcore.Level) int {
+	switch l {
+	case zapcore.DebugLevel:
+		return 7
+	case zapcore.InfoLevel:
+		return 6
+	case zapcore.WarnLevel:
+		return 4
+	case zapcore.ErrorLevel:
+		return 3
+	case zapcore.DPanicLevel:
+		return 2
+	case zapcore.PanicLevel:
+		return 1
+	case zapcore.FatalLevel:
+		return 0
+	default:
+		return 5
+	}
+}
+
+// syslogCore writes entries encoded by encoder as the MSG part of syslog
+// messages.
+type syslogCore struct {
+	zapcore.LevelEnabler
+	encoder zapcore.Encoder
+	writer  *syslogWriter
+}
+
+func (c *syslogCore) With(fields []zapcore.Field) zapcore.Core {
+	encoder := c.encoder.Clone()
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+
+	return &syslogCore{LevelEnabler: c.LevelEnabler, encoder: encoder, writer: c.writer}
+}
+
+func (c *syslogCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *syslogCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.encoder.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+
+	return c.writer.write(ent.Level, ent.Time, bytes.TrimRight(buf.Bytes(), "\r\n"))
+}
+
+func (c *syslogCore) Sync() error {
+	return nil
+}
+
+type syslogWriter struct {
+	network  string
+	address  string
+	facility int
+	hostname string
+	appName  string
+	procID   string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	closed bool
+}
+
+func newSyslogWriter(c SyslogConfig) (*syslogWriter, error) {
+	network, address := c.Network, c.Address
+	if network == "" && address == "" {
+		network, address = "unixgram", defaultSyslogSocket
+	}
+
+	switch network {
+	case "udp", "udp4", "udp6", "tcp", "tcp4", "tcp6", "unix", "unixgram":
+	default:
+		return nil, ErrUnsupportedSyslogNetwork
+	}
+
+	facility := c.Facility
+	if facility == 0 {
+		facility = FacilityUser
+	}
+
+	hostname := c.Hostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+
+	appName := c.AppName
+	if appName == "" {
+		appName = filepath.Base(os.Args[0])
+	}
+
+	w := &syslogWriter{
+		network:  network,
+		address:  address,
+		facility: facility,
+		hostname: headerValue(hostname, 255),
+		appName:  headerValue(appName, 48),
+		procID:   strconv.Itoa(os.Getpid()),
+	}
+
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *syslogWriter) connect() error {
+	conn, err := net.DialTimeout(w.network, w.address, syslogDialTimeout)
+	if err != nil {
+		return err
+	}
+
+	w.conn = conn
+
+	return nil
+}
+
+func (w *syslogWriter) write(l zapcore.Level, t time.Time, msg []byte) error {
+	message := w.format(l, t, msg)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return net.ErrClosed
+	}
+
+	if w.conn != nil {
+		if _, err := w.conn.Write(message); err == nil {
+			return nil
+		}
+
+		w.conn.Close()
+		w.conn = nil
+	}
+
+	// Reconnect once, the server may have restarted.
+	if err := w.connect(); err != nil {
+		return err
+	}
+
+	_, err := w.conn.Write(message)
+
+	return err
+}
+
+// Close closes the connection to the syslog server. Later writes return
+// net.ErrClosed instead of reconnecting.
+func (w *syslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+
+	if w.conn == nil {
+		return nil
+	}
+
+	err := w.conn.Close()
+	w.conn = nil
+
+	return err
+}
+
+// format builds "<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD MSG".
+// Stream transports use octet counting framing from RFC 6587.
+func (w *syslogWriter) format(l zapcore.Level, t time.Time, msg []byte) []byte {
+	var b bytes.Buffer
+
+	b.WriteByte('<')
+	b.WriteString(strconv.Itoa(w.facility*8 + syslogSeverity(l)))
+	b.WriteString(">1 ")
+	b.WriteString(t.Format(syslogTimeFormat))
+	b.WriteByte(' ')
+	b.WriteString(w.hostname)
+	b.WriteByte(' ')
+	b.WriteString(w.appName)
+	b.WriteByte(' ')
+	b.WriteString(w.procID)
+	b.WriteString(" " + syslogNilValue + " " + syslogNilValue + " ")
+	b.Write(msg)
+
+	if !w.isStream() {
+		return b.Bytes()
+	}
+
+	return append([]byte(strconv.Itoa(b.Len())+" "), b.Bytes()...)
+}
+
+func (w *syslogWriter) isStream() bool {
+	switch w.network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		return true
+	default:
+		return false
+	}
+}
+
+// headerValue makes s a valid RFC 5424 header field of printable ASCII
+// without spaces.
+func headerValue(s string, maxLen int) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s) && len(b) < maxLen; i++ {
+		if s[i] > ' ' && s[i] < 127 {
+			b = append(b, s[i])
+		}
+	}
+
+	if len(b) == 0 {
+		return syslogNilValue
+	}
+
+	return string(b)
+}
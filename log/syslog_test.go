@@ -0,0 +1,250 @@
+package log_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ranefattesingh/pkg/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSyslogSink(t *testing.T) {
+	t.Parallel()
+
+	header := regexp.MustCompile(`^<(\d+)>1 \S+ test-host test-app \d+ - - \{.*"message":"disk low".*\}$`)
+
+	testTable := map[string]func(t *testing.T) (network, address string, receive func() string){
+		"should send datagrams over udp": func(t *testing.T) (string, string, func() string) {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("ListenPacket() err = %v", err)
+			}
+			t.Cleanup(func() { conn.Close() })
+
+			return "udp", conn.LocalAddr().String(), func() string {
+				return readPacket(t, conn)
+			}
+		},
+		"should send datagrams over unix sockets": func(t *testing.T) (string, string, func() string) {
+			address := filepath.Join(t.TempDir(), "syslog.sock")
+
+			conn, err := net.ListenPacket("unixgram", address)
+			if err != nil {
+				t.Fatalf("ListenPacket() err = %v", err)
+			}
+			t.Cleanup(func() { conn.Close() })
+
+			return "unixgram", address, func() string {
+				return readPacket(t, conn)
+			}
+		},
+		"should send octet counted frames over tcp": func(t *testing.T) (string, string, func() string) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("Listen() err = %v", err)
+			}
+			t.Cleanup(func() { listener.Close() })
+
+			return "tcp", listener.Addr().String(), func() string {
+				conn, err := listener.Accept()
+				if err != nil {
+					t.Fatalf("Accept() err = %v", err)
+				}
+				defer conn.Close()
+
+				_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+				reader := bufio.NewReader(conn)
+
+				length, err := reader.ReadString(' ')
+				if err != nil {
+					t.Fatalf("ReadString() err = %v", err)
+				}
+
+				size, err := strconv.Atoi(strings.TrimSpace(length))
+				if err != nil {
+					t.Fatalf("frame length %q err = %v", length, err)
+				}
+
+				frame := make([]byte, size)
+				if _, err := io.ReadFull(reader, frame); err != nil {
+					t.Fatalf("ReadFull() err = %v", err)
+				}
+
+				return string(frame)
+			}
+		},
+	}
+
+	for scenario, listen := range testTable {
+		t.Run(scenario, func(t *testing.T) {
+			t.Parallel()
+
+			network, address, receive := listen(t)
+
+			instance, err := log.New(log.Config{
+				Sinks: []log.Sink{{Syslog: &log.SyslogConfig{
+					Network:  network,
+					Address:  address,
+					Facility: log.FacilityLocal0,
+					AppName:  "test-app",
+					Hostname: "test-host",
+				}}},
+			})
+			if err != nil {
+				t.Fatalf("New() err = %v", err)
+			}
+
+			instance.Logger().Warn("disk low")
+
+			message := receive()
+
+			match := header.FindStringSubmatch(message)
+			if match == nil {
+				t.Fatalf("message %q does not match RFC 5424 format", message)
+			}
+
+			// local0 (16) * 8 + warning (4)
+			if match[1] != "132" {
+				t.Errorf("priority = %s, expected 132", match[1])
+			}
+		})
+	}
+}
+
+func TestSyslogSinkClose(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() err = %v", err)
+	}
+	defer listener.Close()
+
+	instance, err := log.New(log.Config{
+		Sinks: []log.Sink{{Syslog: &log.SyslogConfig{Network: "tcp", Address: listener.Addr().String()}}},
+	})
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("Accept() err = %v", err)
+	}
+	defer conn.Close()
+
+	if err := instance.Close(); err != nil {
+		t.Fatalf("Close() err = %v", err)
+	}
+
+	instance.Logger().WithOptions(zap.ErrorOutput(zapcore.AddSync(io.Discard))).Warn("after close")
+
+	_ = listener.(*net.TCPListener).SetDeadline(time.Now().Add(200 * time.Millisecond))
+
+	if conn, err := listener.Accept(); err == nil {
+		conn.Close()
+		t.Errorf("write after Close() opened a new connection")
+	}
+}
+
+func TestJournalSink(t *testing.T) {
+	t.Parallel()
+
+	socket := filepath.Join(t.TempDir(), "journal.sock")
+
+	conn, err := net.ListenPacket("unixgram", socket)
+	if err != nil {
+		t.Fatalf("ListenPacket() err = %v", err)
+	}
+	defer conn.Close()
+
+	instance, err := log.New(log.Config{
+		Sinks: []log.Sink{{Journal: &log.JournalConfig{Socket: socket, Identifier: "test-app"}}},
+	})
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+
+	instance.Logger().Named("db").Error("query failed\nretrying",
+		zap.String("db.table", "users"),
+		zap.Int("attempt", 2),
+		zap.String(strings.Repeat("a", 80), "long"),
+	)
+
+	fields := parseJournal(t, []byte(readPacket(t, conn)))
+
+	expected := map[string]string{
+		"MESSAGE":               "query failed\nretrying",
+		"PRIORITY":              "3",
+		"SYSLOG_IDENTIFIER":     "test-app",
+		"LOGGER":                "db",
+		"DB_TABLE":              "users",
+		"ATTEMPT":               "2",
+		strings.Repeat("A", 64): "long",
+	}
+
+	for key, value := range expected {
+		if fields[key] != value {
+			t.Errorf("%s = %q, expected %q", key, fields[key], value)
+		}
+	}
+
+	if fields["CODE_FILE"] == "" || fields["STACKTRACE"] == "" {
+		t.Errorf("missing caller or stacktrace fields in %v", fields)
+	}
+}
+
+func readPacket(t *testing.T, conn net.PacketConn) string {
+	t.Helper()
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	buf := make([]byte, 64*1024)
+
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom() err = %v", err)
+	}
+
+	return string(buf[:n])
+}
+
+// parseJournal decodes a datagram of the journal native protocol.
+func parseJournal(t *testing.T, datagram []byte) map[string]string {
+	t.Helper()
+
+	fields := map[string]string{}
+
+	for len(datagram) > 0 {
+		end := bytes.IndexByte(datagram, '\n')
+		if end < 0 {
+			t.Fatalf("unterminated field in %q", datagram)
+		}
+
+		line := datagram[:end]
+		if name, value, ok := bytes.Cut(line, []byte("=")); ok {
+			fields[string(name)] = string(value)
+			datagram = datagram[end+1:]
+
+			continue
+		}
+
+		rest := datagram[end+1:]
+		size := binary.LittleEndian.Uint64(rest[:8])
+		fields[string(line)] = string(rest[8 : 8+size])
+		datagram = rest[8+size+1:]
+	}
+
+	return fields
+}
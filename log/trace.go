@@ -0,0 +1,48 @@
+package log
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// Ctx returns the global logger with the trace_id and span_id of the span in
+// ctx attached. Without a valid span the global logger is returned as is.
+func Ctx(ctx context.Context) *zap.Logger {
+	return WithTrace(ctx, Logger())
+}
+
+// WithTrace returns l with the trace_id and span_id of the span in ctx
+// attached.
+func WithTrace(ctx context.Context, l *zap.Logger) *zap.Logger {
+	fields := TraceFields(ctx)
+	if len(fields) == 0 {
+		return l
+	}
+
+	return l.With(fields...)
+}
+
+// TraceFields returns the trace_id and span_id fields of the span in ctx, or
+// nil if ctx carries no valid span context.
+func TraceFields(ctx context.Context) []zap.Field {
+	if ctx == nil {
+		return nil
+	}
+
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return nil
+	}
+
+	return []zap.Field{
+		zap.String(TraceIDKey, spanContext.TraceID().String()),
+		zap.String(SpanIDKey, spanContext.SpanID().String()),
+	}
+}
@@ -0,0 +1,77 @@
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/ranefattesingh/pkg/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTraceCorrelation(t *testing.T) {
+	t.Parallel()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	defer provider.Shutdown(context.Background())
+
+	var buf bytes.Buffer
+
+	instance, err := log.New(log.Config{Output: &buf})
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+
+	ctx, span := provider.Tracer("log_test").Start(context.Background(), "operation")
+	log.WithTrace(ctx, instance.Logger()).Info("inside span")
+	slog.New(log.NewSlogHandler(instance.Logger())).InfoContext(ctx, "inside span via slog")
+	span.End()
+
+	log.WithTrace(context.Background(), instance.Logger()).Info("outside span")
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("exported %d spans, expected 1", len(spans))
+	}
+
+	expectedTraceID := spans[0].SpanContext.TraceID().String()
+	expectedSpanID := spans[0].SpanContext.SpanID().String()
+
+	decoder := json.NewDecoder(&buf)
+	for _, expected := range []struct {
+		message string
+		traced  bool
+	}{
+		{"inside span", true},
+		{"inside span via slog", true},
+		{"outside span", false},
+	} {
+		entry := map[string]any{}
+		if err := decoder.Decode(&entry); err != nil {
+			t.Fatalf("Decode() err = %v", err)
+		}
+
+		if entry["message"] != expected.message {
+			t.Fatalf("message = %v, expected %q", entry["message"], expected.message)
+		}
+
+		traceID, spanID := entry[log.TraceIDKey], entry[log.SpanIDKey]
+		if !expected.traced {
+			if traceID != nil || spanID != nil {
+				t.Errorf("%q has trace fields %v %v", expected.message, traceID, spanID)
+			}
+
+			continue
+		}
+
+		if traceID != expectedTraceID || spanID != expectedSpanID {
+			t.Errorf("%q trace fields = %v %v, expected %s %s",
+				expected.message, traceID, spanID, expectedTraceID, expectedSpanID)
+		}
+	}
+}
@@ -24,6 +24,12 @@ func (p *Pool) CloseDatabaseConnectionPool() {
 	p.pool.Close()
 }
 
+// Ping checks that a connection can be acquired and used, e.g. as a readiness
+// check.
+func (p *Pool) Ping(ctx context.Context) error {
+	return p.pool.Ping(ctx)
+}
+
 func (p *Pool) Connection() *pgxpool.Pool {
 	return p.pool
 }
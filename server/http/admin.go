@@ -0,0 +1,40 @@
+package http
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/ranefattesingh/pkg/log"
+)
+
+// NewAdminHandler returns a handler for an internal admin listener serving
+// pprof under /debug/pprof/, the log level under /log/level and, when not
+// nil, the health endpoints of health and the metrics of metrics under
+// /metrics.
+//
+// The handlers of net/http/pprof are used as they are. Importing that package
+// also registers them on http.DefaultServeMux, so programs using this package
+// must not serve http.DefaultServeMux on a public listener.
+func NewAdminHandler(health *Health, metrics *Metrics) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	mux.Handle("/log/level", log.LevelHandler())
+
+	if health != nil {
+		mux.Handle("/livez", health.LivenessHandler())
+		mux.Handle("/readyz", health.ReadinessHandler())
+		mux.Handle("/healthz", health.Handler())
+	}
+
+	if metrics != nil {
+		mux.Handle("/metrics", metrics.Handler())
+	}
+
+	return mux
+}
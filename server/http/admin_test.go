@@ -0,0 +1,82 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	httpserver "github.com/ranefattesingh/pkg/server/http"
+)
+
+func TestNewAdminHandler(t *testing.T) {
+	t.Parallel()
+
+	metrics, err := httpserver.NewMetrics(httpserver.MetricsConfig{Registry: prometheus.NewRegistry()})
+	if err != nil {
+		t.Fatalf("NewMetrics() err = %v", err)
+	}
+
+	testTable := map[string]struct {
+		health         *httpserver.Health
+		metrics        *httpserver.Metrics
+		path           string
+		expectedStatus int
+	}{
+		"should serve pprof": {
+			path:           "/debug/pprof/",
+			expectedStatus: http.StatusOK,
+		},
+		"should serve a profile": {
+			path:           "/debug/pprof/goroutine?debug=1",
+			expectedStatus: http.StatusOK,
+		},
+		"should not serve an unknown profile": {
+			path:           "/debug/pprof/unknown",
+			expectedStatus: http.StatusNotFound,
+		},
+		"should serve the command line": {
+			path:           "/debug/pprof/cmdline",
+			expectedStatus: http.StatusOK,
+		},
+		"should serve symbols": {
+			path:           "/debug/pprof/symbol",
+			expectedStatus: http.StatusOK,
+		},
+		"should serve a trace": {
+			path:           "/debug/pprof/trace?seconds=0.01",
+			expectedStatus: http.StatusOK,
+		},
+		"should serve the log level": {
+			path:           "/log/level",
+			expectedStatus: http.StatusOK,
+		},
+		"should serve health endpoints": {
+			health:         httpserver.NewHealth(0),
+			path:           "/readyz",
+			expectedStatus: http.StatusOK,
+		},
+		"should serve metrics": {
+			metrics:        metrics,
+			path:           "/metrics",
+			expectedStatus: http.StatusOK,
+		},
+		"should not serve metrics without them": {
+			path:           "/metrics",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for scenario, tc := range testTable {
+		t.Run(scenario, func(t *testing.T) {
+			t.Parallel()
+
+			rw := httptest.NewRecorder()
+			httpserver.NewAdminHandler(tc.health, tc.metrics).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if rw.Code != tc.expectedStatus {
+				t.Errorf("status = %d, expected %d", rw.Code, tc.expectedStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const corsWildcard = "*"
+
+var defaultCORSMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+
+// CORSConfig is the cross-origin policy applied by CORS.
+type CORSConfig struct {
+	// AllowedOrigins are exact origins like "https://app.example.com",
+	// wildcard subdomains like "https://*.example.com", which does not match
+	// "https://example.com" itself, or "*" for any origin.
+	AllowedOrigins []string
+	// AllowedMethods defaults to GET, HEAD and POST.
+	AllowedMethods []string
+	// AllowedHeaders are the request headers a client may send besides the
+	// CORS-safelisted ones. "*" allows any header.
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers scripts may read besides the
+	// CORS-safelisted ones.
+	ExposedHeaders []string
+	// AllowCredentials lets clients send cookies and authorization headers.
+	// The origin is then echoed instead of answering "*".
+	AllowCredentials bool
+	// MaxAge is how long a preflight response may be cached. Zero leaves it
+	// to the browser default.
+	MaxAge time.Duration
+}
+
+type corsPolicy struct {
+	anyOrigin        bool
+	origins          map[string]struct{}
+	wildcardOrigins  []wildcardOrigin
+	methods          map[string]struct{}
+	allowedMethods   string
+	anyHeader        bool
+	headers          map[string]struct{}
+	exposedHeaders   string
+	allowCredentials bool
+	maxAge           string
+}
+
+// wildcardOrigin matches origins starting with prefix, e.g. "https://", and
+// ending with suffix, e.g. ".example.com".
+type wildcardOrigin struct {
+	prefix string
+	suffix string
+}
+
+// CORS answers preflight requests and adds the CORS headers to responses to
+// allowed origins. Preflight requests are answered with 204 and never reach
+// the wrapped handler; disallowed preflights get no CORS headers so the
+// browser blocks the actual request.
+func CORS(c CORSConfig) Middleware {
+	p := newCORSPolicy(c)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			// Responses depend on the origin, so caches must not share them.
+			rw.Header().Add("Vary", "Origin")
+
+			if preflight {
+				rw.Header().Add("Vary", "Access-Control-Request-Method")
+				rw.Header().Add("Vary", "Access-Control-Request-Headers")
+
+				p.preflight(rw, r, origin)
+				rw.WriteHeader(http.StatusNoContent)
+
+				return
+			}
+
+			if origin != "" && p.allowsOrigin(origin) {
+				p.setOrigin(rw, origin)
+
+				if p.exposedHeaders != "" {
+					rw.Header().Set("Access-Control-Expose-Headers", p.exposedHeaders)
+				}
+			}
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+func newCORSPolicy(c CORSConfig) *corsPolicy {
+	p := &corsPolicy{
+		origins:          make(map[string]struct{}),
+		methods:          make(map[string]struct{}),
+		headers:          make(map[string]struct{}),
+		exposedHeaders:   strings.Join(c.ExposedHeaders, ", "),
+		allowCredentials: c.AllowCredentials,
+	}
+
+	for _, origin := range c.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+
+		switch {
+		case origin == corsWildcard:
+			p.anyOrigin = true
+		case strings.Contains(origin, "://*."):
+			scheme, host, _ := strings.Cut(origin, "://*")
+			p.wildcardOrigins = append(p.wildcardOrigins, wildcardOrigin{prefix: scheme + "://", suffix: host})
+		default:
+			p.origins[origin] = struct{}{}
+		}
+	}
+
+	allowedMethods := c.AllowedMethods
+	if len(allowedMethods) == 0 {
+		allowedMethods = defaultCORSMethods
+	}
+
+	methods := make([]string, 0, len(allowedMethods))
+	for _, method := range allowedMethods {
+		method = strings.ToUpper(strings.TrimSpace(method))
+		p.methods[method] = struct{}{}
+		methods = append(methods, method)
+	}
+
+	p.allowedMethods = strings.Join(methods, ", ")
+
+	for _, header := range c.AllowedHeaders {
+		if header == corsWildcard {
+			p.anyHeader = true
+
+			continue
+		}
+
+		p.headers[http.CanonicalHeaderKey(header)] = struct{}{}
+	}
+
+	if c.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(c.MaxAge.Seconds()))
+	}
+
+	return p
+}
+
+func (p *corsPolicy) preflight(rw http.ResponseWriter, r *http.Request, origin string) {
+	if origin == "" || !p.allowsOrigin(origin) {
+		return
+	}
+
+	if _, ok := p.methods[strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))]; !ok {
+		return
+	}
+
+	requestedHeaders := r.Header.Get("Access-Control-Request-Headers")
+	if !p.allowsHeaders(requestedHeaders) {
+		return
+	}
+
+	p.setOrigin(rw, origin)
+	rw.Header().Set("Access-Control-Allow-Methods", p.allowedMethods)
+
+	if requestedHeaders != "" {
+		// Echoing the requested headers also covers "*", which browsers do
+		// not honour for credentialed requests.
+		rw.Header().Set("Access-Control-Allow-Headers", requestedHeaders)
+	}
+
+	if p.maxAge != "" {
+		rw.Header().Set("Access-Control-Max-Age", p.maxAge)
+	}
+}
+
+func (p *corsPolicy) setOrigin(rw http.ResponseWriter, origin string) {
+	if p.anyOrigin && !p.allowCredentials {
+		rw.Header().Set("Access-Control-Allow-Origin", corsWildcard)
+
+		return
+	}
+
+	rw.Header().Set("Access-Control-Allow-Origin", origin)
+
+	if p.allowCredentials {
+		rw.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (p *corsPolicy) allowsOrigin(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+
+	if _, ok := p.origins[origin]; ok {
+		return true
+	}
+
+	for _, wildcard := range p.wildcardOrigins {
+		if len(origin) > len(wildcard.prefix)+len(wildcard.suffix) &&
+			strings.HasPrefix(origin, wildcard.prefix) && strings.HasSuffix(origin, wildcard.suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *corsPolicy) allowsHeaders(requested string) bool {
+	if p.anyHeader || requested == "" {
+		return true
+	}
+
+	for _, header := range strings.Split(requested, ",") {
+		header = strings.TrimSpace(header)
+		if header == "" {
+			continue
+		}
+
+		if _, ok := p.headers[http.CanonicalHeaderKey(header)]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,149 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	httpserver "github.com/ranefattesingh/pkg/server/http"
+)
+
+func TestCORS(t *testing.T) {
+	t.Parallel()
+
+	config := httpserver.CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.example.org"},
+		AllowedMethods:   []string{http.MethodGet, http.MethodPut},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+
+	testTable := map[string]struct {
+		config          httpserver.CORSConfig
+		method          string
+		headers         map[string]string
+		expectedStatus  int
+		expectedHeaders map[string]string
+		expectedHandled bool
+	}{
+		"should answer an allowed preflight": {
+			config: config,
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  http.MethodPut,
+				"Access-Control-Request-Headers": "content-type, authorization",
+			},
+			expectedStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Methods":     "GET, PUT",
+				"Access-Control-Allow-Headers":     "content-type, authorization",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Max-Age":           "600",
+				"Vary":                             "Origin",
+			},
+		},
+		"should not allow a preflight for a disallowed method": {
+			config: config,
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": http.MethodDelete,
+			},
+			expectedStatus:  http.StatusNoContent,
+			expectedHeaders: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		"should not allow a preflight for a disallowed header": {
+			config: config,
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  http.MethodGet,
+				"Access-Control-Request-Headers": "X-Debug",
+			},
+			expectedStatus:  http.StatusNoContent,
+			expectedHeaders: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		"should allow a wildcard subdomain": {
+			config:         config,
+			method:         http.MethodGet,
+			headers:        map[string]string{"Origin": "https://shop.eu.example.org"},
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":   "https://shop.eu.example.org",
+				"Access-Control-Expose-Headers": "X-Request-ID",
+				"Vary":                          "Origin",
+			},
+			expectedHandled: true,
+		},
+		"should not match the parent of a wildcard subdomain": {
+			config:          config,
+			method:          http.MethodGet,
+			headers:         map[string]string{"Origin": "https://example.org"},
+			expectedStatus:  http.StatusOK,
+			expectedHeaders: map[string]string{"Access-Control-Allow-Origin": "", "Vary": "Origin"},
+			expectedHandled: true,
+		},
+		"should not allow an unknown origin": {
+			config:          config,
+			method:          http.MethodGet,
+			headers:         map[string]string{"Origin": "https://evil.com"},
+			expectedStatus:  http.StatusOK,
+			expectedHeaders: map[string]string{"Access-Control-Allow-Origin": ""},
+			expectedHandled: true,
+		},
+		"should answer any origin with a wildcard without credentials": {
+			config:          httpserver.CORSConfig{AllowedOrigins: []string{"*"}},
+			method:          http.MethodGet,
+			headers:         map[string]string{"Origin": "https://anything.test"},
+			expectedStatus:  http.StatusOK,
+			expectedHeaders: map[string]string{"Access-Control-Allow-Origin": "*"},
+			expectedHandled: true,
+		},
+		"should pass plain OPTIONS requests to the handler": {
+			config:          config,
+			method:          http.MethodOptions,
+			headers:         map[string]string{"Origin": "https://app.example.com"},
+			expectedStatus:  http.StatusOK,
+			expectedHeaders: map[string]string{"Access-Control-Allow-Origin": "https://app.example.com"},
+			expectedHandled: true,
+		},
+	}
+
+	for scenario, tc := range testTable {
+		t.Run(scenario, func(t *testing.T) {
+			t.Parallel()
+
+			handled := false
+			handler := httpserver.Chain(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				handled = true
+			}), httpserver.CORS(tc.config))
+
+			r := httptest.NewRequest(tc.method, "/orders", nil)
+			for key, value := range tc.headers {
+				r.Header.Set(key, value)
+			}
+
+			rw := httptest.NewRecorder()
+			handler.ServeHTTP(rw, r)
+
+			if rw.Code != tc.expectedStatus {
+				t.Errorf("status = %d, expected %d", rw.Code, tc.expectedStatus)
+			}
+
+			if handled != tc.expectedHandled {
+				t.Errorf("handled = %v, expected %v", handled, tc.expectedHandled)
+			}
+
+			for key, expected := range tc.expectedHeaders {
+				if actual := rw.Header().Get(key); actual != expected {
+					t.Errorf("%s = %q, expected %q", key, actual, expected)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	HealthStatusOK      = "ok"
+	HealthStatusFailing = "failing"
+
+	defaultHealthCheckTimeout = 5 * time.Second
+	shuttingDownCheckName     = "shutdown"
+)
+
+// HealthCheck reports whether a component, e.g. a database pool, is healthy.
+type HealthCheck func(ctx context.Context) error
+
+// HealthReport is the JSON body returned by the health endpoints.
+type HealthReport struct {
+	Status string                       `json:"status"`
+	Checks map[string]HealthCheckResult `json:"checks,omitempty"`
+}
+
+// HealthCheckResult is the outcome of a single named check.
+type HealthCheckResult struct {
+	Status  string `json:"status"`
+	Latency string `json:"latency"`
+	Error   string `json:"error,omitempty"`
+}
+
+type namedHealthCheck struct {
+	name  string
+	check HealthCheck
+}
+
+// Health serves liveness and readiness endpoints from registered checks.
+// Readiness fails as soon as shutdown begins so load balancers stop sending
+// traffic while in-flight requests drain.
+type Health struct {
+	timeout time.Duration
+
+	mu              sync.RWMutex
+	livenessChecks  []namedHealthCheck
+	readinessChecks []namedHealthCheck
+
+	shuttingDown atomic.Bool
+}
+
+// NewHealth returns a Health whose checks time out after timeout. Zero uses
+// 5 seconds.
+func NewHealth(timeout time.Duration) *Health {
+	if timeout <= 0 {
+		timeout = defaultHealthCheckTimeout
+	}
+
+	return &Health{timeout: timeout}
+}
+
+// AddLivenessCheck registers a check that fails /livez and /healthz. Use it
+// only for failures a restart can fix.
+func (h *Health) AddLivenessCheck(name string, check HealthCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.livenessChecks = append(h.livenessChecks, namedHealthCheck{name: name, check: check})
+}
+
+// AddReadinessCheck registers a check that fails /readyz and /healthz, e.g. a
+// ping of a dependency the service cannot serve requests without.
+func (h *Health) AddReadinessCheck(name string, check HealthCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.readinessChecks = append(h.readinessChecks, namedHealthCheck{name: name, check: check})
+}
+
+// Shutdown makes readiness fail from now on. httpServer calls it when
+// shutdown begins if the Health was registered with WithHealth.
+func (h *Health) Shutdown() {
+	h.shuttingDown.Store(true)
+}
+
+// Handler serves /livez, /readyz and /healthz, which runs every check.
+func (h *Health) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/livez", h.LivenessHandler())
+	mux.Handle("/readyz", h.ReadinessHandler())
+	mux.Handle("/healthz", http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		h.mu.RLock()
+		checks := append(append([]namedHealthCheck{}, h.livenessChecks...), h.readinessChecks...)
+		h.mu.RUnlock()
+
+		h.serve(rw, r, checks, true)
+	}))
+
+	return mux
+}
+
+// LivenessHandler runs the liveness checks.
+func (h *Health) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		h.mu.RLock()
+		checks := append([]namedHealthCheck{}, h.livenessChecks...)
+		h.mu.RUnlock()
+
+		h.serve(rw, r, checks, false)
+	})
+}
+
+// ReadinessHandler runs the readiness checks and fails once shutdown begins.
+func (h *Health) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		h.mu.RLock()
+		checks := append([]namedHealthCheck{}, h.readinessChecks...)
+		h.mu.RUnlock()
+
+		h.serve(rw, r, checks, true)
+	})
+}
+
+func (h *Health) serve(rw http.ResponseWriter, r *http.Request, checks []namedHealthCheck, shutdownAware bool) {
+	report := h.run(r.Context(), checks)
+
+	if shutdownAware && h.shuttingDown.Load() {
+		report.Status = HealthStatusFailing
+		report.Checks[shuttingDownCheckName] = HealthCheckResult{
+			Status:  HealthStatusFailing,
+			Latency: time.Duration(0).String(),
+			Error:   "server is shutting down",
+		}
+	}
+
+	status := http.StatusOK
+	if report.Status != HealthStatusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.WriteHeader(status)
+
+	_ = json.NewEncoder(rw).Encode(report)
+}
+
+// run executes checks concurrently, each bounded by the health timeout.
+func (h *Health) run(ctx context.Context, checks []namedHealthCheck) HealthReport {
+	report := HealthReport{
+		Status: HealthStatusOK,
+		Checks: make(map[string]HealthCheckResult, len(checks)),
+	}
+
+	results := make([]HealthCheckResult, len(checks))
+
+	var wg sync.WaitGroup
+
+	wg.Add(len(checks))
+
+	for i, check := range checks {
+		go func() {
+			defer wg.Done()
+
+			results[i] = h.runCheck(ctx, check.check)
+		}()
+	}
+
+	wg.Wait()
+
+	for i, check := range checks {
+		report.Checks[check.name] = results[i]
+
+		if results[i].Status != HealthStatusOK {
+			report.Status = HealthStatusFailing
+		}
+	}
+
+	return report
+}
+
+func (h *Health) runCheck(ctx context.Context, check HealthCheck) HealthCheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	// Checks ignoring ctx still cannot hold the response past the timeout.
+	done := make(chan error, 1)
+
+	go func() {
+		done <- check(ctx)
+	}()
+
+	var err error
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := HealthCheckResult{
+		Status:  HealthStatusOK,
+		Latency: time.Since(start).String(),
+	}
+
+	if err != nil {
+		result.Status = HealthStatusFailing
+		result.Error = err.Error()
+	}
+
+	return result
+}
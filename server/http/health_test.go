@@ -0,0 +1,189 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	httpserver "github.com/ranefattesingh/pkg/server/http"
+)
+
+func TestHealthHandler(t *testing.T) {
+	t.Parallel()
+
+	ok := func(context.Context) error { return nil }
+	failing := func(context.Context) error { return errors.New("connection refused") }
+	hanging := func(ctx context.Context) error {
+		<-ctx.Done()
+
+		return ctx.Err()
+	}
+
+	testTable := map[string]struct {
+		path           string
+		liveness       httpserver.HealthCheck
+		readiness      httpserver.HealthCheck
+		shutdown       bool
+		expectedStatus int
+		expectedChecks map[string]string
+	}{
+		"should report healthy liveness": {
+			path:           "/livez",
+			liveness:       ok,
+			readiness:      failing,
+			expectedStatus: http.StatusOK,
+			expectedChecks: map[string]string{"live": httpserver.HealthStatusOK},
+		},
+		"should fail readiness when a check fails": {
+			path:           "/readyz",
+			liveness:       ok,
+			readiness:      failing,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedChecks: map[string]string{"ready": httpserver.HealthStatusFailing},
+		},
+		"should fail a check exceeding the timeout": {
+			path:           "/readyz",
+			liveness:       ok,
+			readiness:      hanging,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedChecks: map[string]string{"ready": httpserver.HealthStatusFailing},
+		},
+		"should run every check on healthz": {
+			path:           "/healthz",
+			liveness:       ok,
+			readiness:      ok,
+			expectedStatus: http.StatusOK,
+			expectedChecks: map[string]string{"live": httpserver.HealthStatusOK, "ready": httpserver.HealthStatusOK},
+		},
+		"should fail readiness once shutdown begins": {
+			path:           "/readyz",
+			liveness:       ok,
+			readiness:      ok,
+			shutdown:       true,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedChecks: map[string]string{"ready": httpserver.HealthStatusOK, "shutdown": httpserver.HealthStatusFailing},
+		},
+		"should keep liveness healthy during shutdown": {
+			path:           "/livez",
+			liveness:       ok,
+			readiness:      ok,
+			shutdown:       true,
+			expectedStatus: http.StatusOK,
+			expectedChecks: map[string]string{"live": httpserver.HealthStatusOK},
+		},
+	}
+
+	for scenario, tc := range testTable {
+		t.Run(scenario, func(t *testing.T) {
+			t.Parallel()
+
+			health := httpserver.NewHealth(50 * time.Millisecond)
+			health.AddLivenessCheck("live", tc.liveness)
+			health.AddReadinessCheck("ready", tc.readiness)
+
+			if tc.shutdown {
+				health.Shutdown()
+			}
+
+			rw := httptest.NewRecorder()
+			health.Handler().ServeHTTP(rw, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if rw.Code != tc.expectedStatus {
+				t.Errorf("status = %d, expected %d", rw.Code, tc.expectedStatus)
+			}
+
+			var report httpserver.HealthReport
+			if err := json.Unmarshal(rw.Body.Bytes(), &report); err != nil {
+				t.Fatalf("Unmarshal() err = %v", err)
+			}
+
+			if len(report.Checks) != len(tc.expectedChecks) {
+				t.Errorf("checks = %v, expected %v", report.Checks, tc.expectedChecks)
+			}
+
+			for name, status := range tc.expectedChecks {
+				result, ok := report.Checks[name]
+				if !ok {
+					t.Errorf("check %q missing from %v", name, report.Checks)
+
+					continue
+				}
+
+				if result.Status != status {
+					t.Errorf("check %q status = %s, expected %s", name, result.Status, status)
+				}
+
+				if result.Latency == "" {
+					t.Errorf("check %q latency is empty", name)
+				}
+			}
+		})
+	}
+}
+
+func TestStartContextFailsReadiness(t *testing.T) {
+	t.Parallel()
+
+	health := httpserver.NewHealth(0)
+
+	host, port := freeAddr(t)
+	server := httpserver.NewHTTPServer(host, port,
+		httpserver.WithHealth(health),
+		httpserver.WithShutdownDelay(time.Second),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- server.StartContext(ctx, health.Handler(), nil)
+	}()
+
+	waitForServer(t, host, port)
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	url := "http://" + net.JoinHostPort(host, strconv.Itoa(port)) + "/readyz"
+
+	readyStatus := func() int {
+		t.Helper()
+
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatalf("Get() err = %v, expected the server to keep serving", err)
+		}
+		defer resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	if status := readyStatus(); status != http.StatusOK {
+		t.Fatalf("status before shutdown = %d, expected %d", status, http.StatusOK)
+	}
+
+	cancel()
+
+	deadline := time.Now().Add(500 * time.Millisecond)
+	for readyStatus() != http.StatusServiceUnavailable {
+		if time.Now().After(deadline) {
+			t.Fatal("readiness did not fail during the shutdown delay")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case err := <-errChan:
+		t.Fatalf("StartContext() returned %v during the shutdown delay", err)
+	default:
+	}
+
+	if err := <-errChan; err != nil {
+		t.Errorf("StartContext() err = %v, expected nil", err)
+	}
+}
@@ -0,0 +1,202 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+const (
+	// systemdListenFDsStart is the first file descriptor passed by systemd
+	// socket activation.
+	systemdListenFDsStart = 3
+
+	envListenPID     = "LISTEN_PID"
+	envListenFDs     = "LISTEN_FDS"
+	envListenFDNames = "LISTEN_FDNAMES"
+)
+
+// listenerConfig is a listener served alongside the primary one. Either
+// listener is set, or it is opened on network and address at start.
+type listenerConfig struct {
+	network  string
+	address  string
+	listener net.Listener
+	handler  http.Handler
+}
+
+// boundListener is a listener ready to be served with handler. Only the
+// primary listener uses the TLS settings and read and write timeouts.
+type boundListener struct {
+	listener net.Listener
+	handler  http.Handler
+	primary  bool
+	tls      bool
+}
+
+// listen opens every listener. The primary listener serves handler with the
+// middlewares, TLS settings and read and write timeouts of the server;
+// additional listeners serve their own handler without them. Listeners
+// inherited from a restarting parent replace the configured ones in the same
+// order. If any listener fails to open, the ones already open are closed.
+func (h *httpServer) listen(handler http.Handler) ([]boundListener, error) {
+	configs := append([]listenerConfig{{
+		network:  "tcp",
+		address:  h.addr,
+		listener: h.listener,
+		handler:  Chain(handler, h.middlewares...),
+	}}, h.listeners...)
+
+	inherited, err := h.inheritedListeners()
+	if err == nil && inherited != nil && len(inherited) != len(configs) {
+		err = fmt.Errorf("%w: got %d, expected %d", ErrInheritedListenerCount, len(inherited), len(configs))
+
+		for _, listener := range inherited {
+			_ = listener.Close()
+		}
+
+		inherited = nil
+	}
+
+	listeners := make([]net.Listener, len(configs))
+	copy(listeners, inherited)
+
+	closeAll := func() {
+		for i, config := range configs {
+			if listeners[i] != nil {
+				_ = listeners[i].Close()
+			}
+
+			if config.listener != nil && config.listener != listeners[i] {
+				_ = config.listener.Close()
+			}
+		}
+	}
+
+	if err != nil {
+		closeAll()
+
+		return nil, err
+	}
+
+	bound := make([]boundListener, 0, len(configs))
+
+	for i, config := range configs {
+		switch {
+		case listeners[i] != nil:
+			// Inherited, the pre-opened listener of the configuration is
+			// not used.
+			if config.listener != nil {
+				_ = config.listener.Close()
+			}
+		case config.listener != nil:
+			listeners[i] = config.listener
+		default:
+			listeners[i], err = openListener(config.network, config.address)
+			if err != nil {
+				closeAll()
+
+				return nil, err
+			}
+		}
+
+		bound = append(bound, boundListener{
+			listener: listeners[i],
+			handler:  config.handler,
+			primary:  i == 0,
+			tls:      i == 0 && h.usesTLS(),
+		})
+	}
+
+	return bound, nil
+}
+
+// openListener listens on network and address. A stale unix socket left by a
+// previous process is removed first. Sockets still served by another process
+// and other files at the path are kept, so listening fails.
+func openListener(network, address string) (net.Listener, error) {
+	if network == "unix" && staleSocket(address) {
+		if err := os.Remove(address); err != nil {
+			return nil, err
+		}
+	}
+
+	return net.Listen(network, address)
+}
+
+// staleSocket reports whether path is a unix socket nobody listens on.
+func staleSocket(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Type() != fs.ModeSocket {
+		return false
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err == nil {
+		_ = conn.Close()
+
+		return false
+	}
+
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// SystemdListeners returns the listeners passed by systemd socket activation
+// in the order of the ListenStream= lines of the socket unit. It returns no
+// listeners if the process was not socket activated.
+func SystemdListeners() ([]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv(envListenPID))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+
+	count, err := strconv.Atoi(os.Getenv(envListenFDs))
+	if err != nil || count == 0 {
+		return nil, nil
+	}
+
+	// The variables are meant for this process only, not its children.
+	_ = os.Unsetenv(envListenPID)
+	_ = os.Unsetenv(envListenFDs)
+	_ = os.Unsetenv(envListenFDNames)
+
+	return filesListeners(systemdListenFDsStart, count)
+}
+
+// filesListeners turns count inherited file descriptors starting at start
+// into listeners. The descriptors are duplicated with close-on-exec set and
+// the originals closed.
+func filesListeners(start, count int) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, count)
+	errs := make([]error, 0)
+
+	for fd := start; fd < start+count; fd++ {
+		file := os.NewFile(uintptr(fd), "listener-"+strconv.Itoa(fd))
+
+		listener, err := net.FileListener(file)
+		_ = file.Close()
+
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		listeners = append(listeners, listener)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		for _, listener := range listeners {
+			_ = listener.Close()
+		}
+
+		return nil, err
+	}
+
+	return listeners, nil
+}
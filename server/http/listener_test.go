@@ -0,0 +1,296 @@
+package http_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	httpserver "github.com/ranefattesingh/pkg/server/http"
+)
+
+func TestStartContextMultipleListeners(t *testing.T) {
+	t.Parallel()
+
+	host, port := freeAddr(t)
+	adminHost, adminPort := freeAddr(t)
+	socket := filepath.Join(t.TempDir(), "app.sock")
+
+	preopened, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() err = %v", err)
+	}
+
+	respond := func(body string) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(rw, body)
+		})
+	}
+
+	server := httpserver.NewHTTPServer(host, port,
+		httpserver.WithAdminServer(adminHost, adminPort, respond("admin")),
+		httpserver.WithAdditionalAddr("unix", socket, respond("unix")),
+		httpserver.WithAdditionalListener(preopened, respond("preopened")),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- server.StartContext(ctx, respond("public"), nil)
+	}()
+
+	waitForServer(t, host, port)
+
+	unixClient := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+
+	testTable := map[string]struct {
+		client   *http.Client
+		url      string
+		expected string
+	}{
+		"should serve the primary listener": {
+			client:   http.DefaultClient,
+			url:      "http://" + net.JoinHostPort(host, strconv.Itoa(port)),
+			expected: "public",
+		},
+		"should serve the admin server": {
+			client:   http.DefaultClient,
+			url:      "http://" + net.JoinHostPort(adminHost, strconv.Itoa(adminPort)),
+			expected: "admin",
+		},
+		"should serve a unix socket": {
+			client:   unixClient,
+			url:      "http://unix",
+			expected: "unix",
+		},
+		"should serve a pre-opened listener": {
+			client:   http.DefaultClient,
+			url:      "http://" + preopened.Addr().String(),
+			expected: "preopened",
+		},
+	}
+
+	for scenario, tc := range testTable {
+		t.Run(scenario, func(t *testing.T) {
+			resp, err := tc.client.Get(tc.url)
+			if err != nil {
+				t.Fatalf("Get() err = %v", err)
+			}
+			defer resp.Body.Close()
+
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tc.expected {
+				t.Errorf("body = %q, expected %q", body, tc.expected)
+			}
+		})
+	}
+
+	cancel()
+
+	select {
+	case err := <-errChan:
+		if err != nil {
+			t.Errorf("StartContext() err = %v, expected nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("StartContext() did not return")
+	}
+
+	for _, addr := range []string{preopened.Addr().String(), net.JoinHostPort(adminHost, strconv.Itoa(adminPort))} {
+		if conn, err := net.Dial("tcp", addr); err == nil {
+			conn.Close()
+			t.Errorf("listener %s still accepts connections after shutdown", addr)
+		}
+	}
+}
+
+func TestStartContextAdditionalListenerTimeouts(t *testing.T) {
+	t.Parallel()
+
+	host, port := freeAddr(t)
+	adminHost, adminPort := freeAddr(t)
+
+	slow := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		_, _ = io.WriteString(rw, "slow")
+	})
+
+	server := httpserver.NewHTTPServer(host, port,
+		httpserver.WithWriteTimeout(50*time.Millisecond),
+		httpserver.WithAdminServer(adminHost, adminPort, slow),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- server.StartContext(ctx, slow, nil)
+	}()
+
+	waitForServer(t, host, port)
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+	testTable := map[string]struct {
+		url         string
+		expectError bool
+	}{
+		"should time out writes on the primary listener": {
+			url:         "http://" + net.JoinHostPort(host, strconv.Itoa(port)),
+			expectError: true,
+		},
+		"should not time out writes on the admin server": {
+			url:         "http://" + net.JoinHostPort(adminHost, strconv.Itoa(adminPort)),
+			expectError: false,
+		},
+	}
+
+	for scenario, tc := range testTable {
+		t.Run(scenario, func(t *testing.T) {
+			resp, err := client.Get(tc.url)
+			if err == nil {
+				defer resp.Body.Close()
+
+				var body []byte
+
+				body, err = io.ReadAll(resp.Body)
+				if err == nil && string(body) != "slow" {
+					t.Errorf("body = %q, expected %q", body, "slow")
+				}
+			}
+
+			if (err != nil) != tc.expectError {
+				t.Errorf("Get() err = %v, expected error %v", err, tc.expectError)
+			}
+		})
+	}
+
+	cancel()
+
+	if err := <-errChan; err != nil {
+		t.Errorf("StartContext() err = %v, expected nil", err)
+	}
+}
+
+func TestStartContextClosesListenersOnBindError(t *testing.T) {
+	t.Parallel()
+
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() err = %v", err)
+	}
+	defer taken.Close()
+
+	host, port := freeAddr(t)
+	server := httpserver.NewHTTPServer(host, port,
+		httpserver.WithAdditionalAddr("tcp", taken.Addr().String(), http.NotFoundHandler()),
+	)
+
+	if err := server.StartContext(context.Background(), http.NotFoundHandler(), nil); err == nil {
+		t.Fatal("StartContext() err = nil, expected bind error")
+	}
+
+	// The primary listener was opened first and must have been released.
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		t.Fatalf("Listen() err = %v, expected the primary address to be free", err)
+	}
+
+	listener.Close()
+}
+
+func TestStartContextUnixSocketInUse(t *testing.T) {
+	t.Parallel()
+
+	testTable := map[string]struct {
+		closeExisting bool
+		expectError   bool
+	}{
+		"should replace a stale socket": {
+			closeExisting: true,
+			expectError:   false,
+		},
+		"should not replace a socket still served": {
+			closeExisting: false,
+			expectError:   true,
+		},
+	}
+
+	for scenario, tc := range testTable {
+		t.Run(scenario, func(t *testing.T) {
+			t.Parallel()
+
+			socket := filepath.Join(t.TempDir(), "app.sock")
+
+			existing, err := net.ListenUnix("unix", &net.UnixAddr{Name: socket, Net: "unix"})
+			if err != nil {
+				t.Fatalf("ListenUnix() err = %v", err)
+			}
+			defer existing.Close()
+
+			if tc.closeExisting {
+				// Leaves the socket file behind, as a crashed process would.
+				existing.SetUnlinkOnClose(false)
+				existing.Close()
+			}
+
+			host, port := freeAddr(t)
+			server := httpserver.NewHTTPServer(host, port,
+				httpserver.WithAdditionalAddr("unix", socket, http.NotFoundHandler()),
+			)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			errChan := make(chan error, 1)
+
+			go func() {
+				errChan <- server.StartContext(ctx, http.NotFoundHandler(), nil)
+			}()
+
+			if !tc.expectError {
+				waitForServer(t, host, port)
+				cancel()
+			}
+
+			select {
+			case err := <-errChan:
+				if (err != nil) != tc.expectError {
+					t.Errorf("StartContext() err = %v, expected error %v", err, tc.expectError)
+				}
+			case <-time.After(5 * time.Second):
+				cancel()
+				<-errChan
+				t.Errorf("StartContext() served, expected error %v", tc.expectError)
+			}
+
+			if !tc.closeExisting {
+				conn, err := net.Dial("unix", socket)
+				if err != nil {
+					t.Fatalf("Dial() err = %v, expected the socket to still be served", err)
+				}
+
+				conn.Close()
+			}
+		})
+	}
+}
+
+func TestSystemdListenersWithoutActivation(t *testing.T) {
+	t.Setenv("LISTEN_PID", "1")
+	t.Setenv("LISTEN_FDS", "1")
+
+	listeners, err := httpserver.SystemdListeners()
+	if err != nil || len(listeners) != 0 {
+		t.Errorf("SystemdListeners() = %v, %v, expected no listeners", listeners, err)
+	}
+}
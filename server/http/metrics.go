@@ -0,0 +1,163 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	unmatchedRoute = "unmatched"
+	otherMethod    = "OTHER"
+)
+
+// MetricsConfig configures the RED metrics recorded by Metrics.
+type MetricsConfig struct {
+	// Namespace prefixes every metric name, e.g. "orders" gives
+	// orders_http_requests_total.
+	Namespace string
+	// Registry defaults to a new registry with the Go runtime and process
+	// collectors registered.
+	Registry *prometheus.Registry
+	// Buckets of the request duration histogram in seconds. Defaults to
+	// prometheus.DefBuckets.
+	Buckets []float64
+}
+
+// Metrics records request rate, errors and duration labelled by method, route
+// pattern and status class, and serves them in the Prometheus text format.
+//
+// The route is the pattern of the http.ServeMux serving the request, e.g.
+// "/orders/{id}", never the raw path, so the number of series stays bounded.
+// Requests no pattern matches are labelled "unmatched".
+type Metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+}
+
+// NewMetrics registers the HTTP metrics in the registry of c.
+func NewMetrics(c MetricsConfig) (*Metrics, error) {
+	registry := c.Registry
+	if registry == nil {
+		registry = prometheus.NewRegistry()
+		registry.MustRegister(
+			collectors.NewGoCollector(),
+			collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		)
+	}
+
+	buckets := c.Buckets
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+
+	m := &Metrics{
+		registry: registry,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: c.Namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of HTTP requests served.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: c.Namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of HTTP requests in seconds.",
+			Buckets:   buckets,
+		}, []string{"method", "route", "status"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: c.Namespace,
+			Subsystem: "http",
+			Name:      "requests_in_flight",
+			Help:      "Number of HTTP requests being served.",
+		}, []string{"method"}),
+	}
+
+	for _, collector := range []prometheus.Collector{m.requests, m.duration, m.inFlight} {
+		if err := registry.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// Middleware records the metrics of every request served by the wrapped
+// handler, labelled by the route mux matches for it. Other middlewares may
+// sit between it and mux.
+func (m *Metrics) Middleware(mux *http.ServeMux) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			method := metricsMethod(r.Method)
+			_, pattern := mux.Handler(r)
+
+			inFlight := m.inFlight.WithLabelValues(method)
+			inFlight.Inc()
+			defer inFlight.Dec()
+
+			start := time.Now()
+			recorder := newResponseRecorder(rw)
+
+			next.ServeHTTP(recorder, r)
+
+			route := metricsRoute(pattern)
+			status := statusClass(recorder.status)
+
+			m.requests.WithLabelValues(method, route, status).Inc()
+			m.duration.WithLabelValues(method, route, status).Observe(time.Since(start).Seconds())
+		})
+	}
+}
+
+// Handler serves the metrics of the registry in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Registry returns the registry the metrics are registered in, e.g. to add
+// application metrics to the same endpoint.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// metricsMethod keeps the standard methods and folds anything else clients
+// send into OTHER.
+func metricsMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return otherMethod
+	}
+}
+
+// metricsRoute drops the method of patterns like "GET /orders/{id}" as it is
+// already a label.
+func metricsRoute(pattern string) string {
+	if pattern == "" {
+		return unmatchedRoute
+	}
+
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		return strings.TrimLeft(path, " \t")
+	}
+
+	return pattern
+}
+
+func statusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+
+	return strconv.Itoa(status/100) + "xx"
+}
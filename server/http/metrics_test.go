@@ -0,0 +1,65 @@
+package http_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	httpserver "github.com/ranefattesingh/pkg/server/http"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	metrics, err := httpserver.NewMetrics(httpserver.MetricsConfig{
+		Namespace: "test",
+		Registry:  prometheus.NewRegistry(),
+	})
+	if err != nil {
+		t.Fatalf("NewMetrics() err = %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /orders/{id}", func(rw http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "missing" {
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	// RequestID replaces the request the mux sees.
+	handler := httpserver.Chain(mux, metrics.Middleware(mux), httpserver.RequestID())
+
+	for _, path := range []string{"/orders/1", "/orders/2", "/orders/missing", "/unknown/path"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PURGE", "/orders/1", nil))
+
+	rw := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body, _ := io.ReadAll(rw.Body)
+	exposition := string(body)
+
+	testTable := map[string]string{
+		"should label requests by route pattern":       `test_http_requests_total{method="GET",route="/orders/{id}",status="2xx"} 2`,
+		"should label requests by status class":        `test_http_requests_total{method="GET",route="/orders/{id}",status="4xx"} 1`,
+		"should not use raw paths of unmatched routes": `test_http_requests_total{method="GET",route="unmatched",status="4xx"} 1`,
+		"should fold unknown methods":                  `test_http_requests_total{method="OTHER",route="unmatched",status="4xx"} 1`,
+		"should record latency histograms":             `test_http_request_duration_seconds_count{method="GET",route="/orders/{id}",status="2xx"} 2`,
+		"should report requests in flight":             `test_http_requests_in_flight{method="GET"} 0`,
+	}
+
+	for scenario, expected := range testTable {
+		t.Run(scenario, func(t *testing.T) {
+			t.Parallel()
+
+			if !strings.Contains(exposition, expected) {
+				t.Errorf("exposition does not contain %q:\n%s", expected, exposition)
+			}
+		})
+	}
+}
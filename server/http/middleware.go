@@ -0,0 +1,233 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/ranefattesingh/pkg/json"
+	"github.com/ranefattesingh/pkg/log"
+	"go.uber.org/zap"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+	RequestIDKey    = "request_id"
+
+	accessLoggerName   = "http"
+	maxRequestIDLength = 128
+)
+
+type requestIDContextKey struct{}
+
+// Middleware wraps a handler with behaviour that runs around it.
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps handler with middlewares. The first middleware is the
+// outermost, so Chain(h, Recover(), RequestID()) runs Recover first.
+func Chain(handler http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+
+	return handler
+}
+
+// Recover turns a panic in the wrapped handler into a 500 json.Error and logs
+// it with its stack trace. http.ErrAbortHandler is re-panicked so the server
+// still aborts the response.
+func Recover() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			recorder := newResponseRecorder(rw)
+
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+
+				if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(recovered)
+				}
+
+				requestLogger(r.Context()).Error("handler panicked",
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+					zap.Any("panic", recovered),
+					zap.Stack("stacktrace"),
+				)
+
+				// A partially written response cannot be replaced by an error.
+				if recorder.wroteHeader {
+					panic(http.ErrAbortHandler)
+				}
+
+				recorder.Header().Set("Content-Type", "application/json")
+				_ = json.EncodeErrorJSON(recorder, &json.Error{
+					HTTPStatusCode: http.StatusInternalServerError,
+					Code:           http.StatusInternalServerError,
+					Message:        http.StatusText(http.StatusInternalServerError),
+				})
+			}()
+
+			next.ServeHTTP(recorder, r)
+		})
+	}
+}
+
+// RequestID propagates the X-Request-ID header of the request, or generates
+// one if it is missing or malformed. The ID is set on the response and can be
+// read from the request context with RequestIDFromContext.
+func RequestID() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+
+			rw.Header().Set(RequestIDHeader, id)
+
+			next.ServeHTTP(rw, r.WithContext(ContextWithRequestID(r.Context(), id)))
+		})
+	}
+}
+
+// ContextWithRequestID returns a copy of ctx carrying id.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID set by RequestID, or "" if ctx
+// carries none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+
+	return id
+}
+
+// AccessLog logs every request to the "http" logger once it has been served,
+// with its method, path, status, bytes written and duration. Server errors
+// are logged at error level, everything else at info.
+func AccessLog() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			recorder := newResponseRecorder(rw)
+
+			next.ServeHTTP(recorder, r)
+
+			fields := []zap.Field{
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+				zap.Int("status", recorder.status),
+				zap.Int64("bytes", recorder.bytes),
+				zap.Duration("duration", time.Since(start)),
+				zap.String("remote_addr", r.RemoteAddr),
+			}
+
+			logger := requestLogger(r.Context())
+			if recorder.status >= http.StatusInternalServerError {
+				logger.Error("request served", fields...)
+
+				return
+			}
+
+			logger.Info("request served", fields...)
+		})
+	}
+}
+
+// requestLogger returns the "http" logger with the request ID and trace of
+// ctx attached.
+func requestLogger(ctx context.Context) *zap.Logger {
+	logger := log.WithTrace(ctx, log.Named(accessLoggerName))
+
+	if id := RequestIDFromContext(ctx); id != "" {
+		logger = logger.With(zap.String(RequestIDKey, id))
+	}
+
+	return logger
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand does not fail on supported platforms; fall back to a
+		// time based ID rather than serving without one.
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// validRequestID rejects IDs that are too long or contain characters other
+// than printable ASCII so clients cannot inject into logs or headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+// responseRecorder records the status and size of a response. Unwrap lets
+// http.ResponseController reach the optional interfaces of the wrapped writer.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func newResponseRecorder(rw http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{ResponseWriter: rw, status: http.StatusOK}
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	// Informational responses are followed by the final one.
+	informational := status >= 100 && status < 200 && status != http.StatusSwitchingProtocols
+
+	if !r.wroteHeader && !informational {
+		r.status = status
+		r.wroteHeader = true
+	}
+
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+
+	return n, err
+}
+
+func (r *responseRecorder) Flush() {
+	r.wroteHeader = true
+
+	_ = http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(r.ResponseWriter).Hijack()
+}
+
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
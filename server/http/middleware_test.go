@@ -0,0 +1,167 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ranefattesingh/pkg/log"
+	"github.com/ranefattesingh/pkg/log/logtest"
+	httpserver "github.com/ranefattesingh/pkg/server/http"
+	"go.uber.org/zap"
+)
+
+func TestChainOrder(t *testing.T) {
+	t.Parallel()
+
+	order := make([]string, 0)
+	middleware := func(name string) httpserver.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(rw, r)
+			})
+		}
+	}
+
+	handler := httpserver.Chain(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	}), middleware("first"), middleware("second"))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if expected := []string{"first", "second", "handler"}; !reflect.DeepEqual(order, expected) {
+		t.Errorf("order = %v, expected %v", order, expected)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	recorder := logtest.New(t)
+
+	handler := httpserver.Chain(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}), httpserver.Recover())
+
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	if rw.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, expected %d", rw.Code, http.StatusInternalServerError)
+	}
+
+	var body struct {
+		Success bool `json:"success"`
+		Error   struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rw.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Unmarshal() err = %v", err)
+	}
+
+	if body.Success || body.Error.Code != http.StatusInternalServerError {
+		t.Errorf("body = %s, expected a 500 error response", rw.Body.String())
+	}
+
+	recorder.AssertLogged(t, log.ErrorLevel, "handler panicked", zap.String("path", "/orders"))
+}
+
+func TestRequestID(t *testing.T) {
+	t.Parallel()
+
+	testTable := map[string]struct {
+		header        string
+		expectedReuse bool
+	}{
+		"should propagate the incoming request ID": {
+			header:        "abc-123",
+			expectedReuse: true,
+		},
+		"should generate a request ID when missing": {
+			header: "",
+		},
+		"should replace a request ID with invalid characters": {
+			header: "abc 123\r\n",
+		},
+		"should replace a request ID that is too long": {
+			header: strings.Repeat("a", 129),
+		},
+	}
+
+	for scenario, tc := range testTable {
+		t.Run(scenario, func(t *testing.T) {
+			t.Parallel()
+
+			var fromContext string
+
+			handler := httpserver.Chain(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				fromContext = httpserver.RequestIDFromContext(r.Context())
+			}), httpserver.RequestID())
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				r.Header.Set(httpserver.RequestIDHeader, tc.header)
+			}
+
+			rw := httptest.NewRecorder()
+			handler.ServeHTTP(rw, r)
+
+			id := rw.Header().Get(httpserver.RequestIDHeader)
+			if id == "" || id != fromContext {
+				t.Errorf("response ID = %q, context ID = %q, expected equal and non-empty", id, fromContext)
+			}
+
+			if reused := id == tc.header; reused != tc.expectedReuse {
+				t.Errorf("ID %q reused = %v, expected %v", id, reused, tc.expectedReuse)
+			}
+		})
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	recorder := logtest.New(t)
+
+	testTable := map[string]struct {
+		status        int
+		body          string
+		expectedLevel log.LogLevel
+	}{
+		"should log successful requests at info": {
+			status:        http.StatusCreated,
+			body:          "created",
+			expectedLevel: log.InfoLevel,
+		},
+		"should log server errors at error": {
+			status:        http.StatusBadGateway,
+			body:          "",
+			expectedLevel: log.ErrorLevel,
+		},
+	}
+
+	for scenario, tc := range testTable {
+		t.Run(scenario, func(t *testing.T) {
+			recorder.Reset()
+
+			handler := httpserver.Chain(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				rw.WriteHeader(tc.status)
+				_, _ = rw.Write([]byte(tc.body))
+			}), httpserver.RequestID(), httpserver.AccessLog())
+
+			r := httptest.NewRequest(http.MethodPost, "/orders", nil)
+			r.Header.Set(httpserver.RequestIDHeader, "req-1")
+
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			recorder.AssertLogged(t, tc.expectedLevel, "request served",
+				zap.String("method", http.MethodPost),
+				zap.String("path", "/orders"),
+				zap.Int("status", tc.status),
+				zap.Int64("bytes", int64(len(tc.body))),
+				zap.String(httpserver.RequestIDKey, "req-1"),
+			)
+		})
+	}
+}
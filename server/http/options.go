@@ -0,0 +1,167 @@
+package http
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultReadHeaderTimeout = 10 * time.Second
+	defaultIdleTimeout       = 120 * time.Second
+	defaultDrainTimeout      = 30 * time.Second
+)
+
+// Option configures an httpServer created by NewHTTPServer.
+type Option func(*httpServer)
+
+// WithCleanupFns registers functions run when the server shuts down, after
+// in-flight requests have drained. They run in reverse order of registration.
+func WithCleanupFns(cleanupFns ...func() error) Option {
+	return func(h *httpServer) {
+		h.cleanupFns = append(h.cleanupFns, cleanupFns...)
+	}
+}
+
+// WithReadTimeout sets the maximum duration for reading an entire request,
+// including the body, on the primary listener. Zero means no timeout.
+func WithReadTimeout(d time.Duration) Option {
+	return func(h *httpServer) {
+		h.readTimeout = d
+	}
+}
+
+// WithReadHeaderTimeout sets the maximum duration for reading request
+// headers. Defaults to 10 seconds.
+func WithReadHeaderTimeout(d time.Duration) Option {
+	return func(h *httpServer) {
+		h.readHeaderTimeout = d
+	}
+}
+
+// WithWriteTimeout sets the maximum duration before timing out writes of the
+// response on the primary listener. Zero means no timeout.
+func WithWriteTimeout(d time.Duration) Option {
+	return func(h *httpServer) {
+		h.writeTimeout = d
+	}
+}
+
+// WithIdleTimeout sets the maximum time to wait for the next request on a
+// keep-alive connection. Defaults to 120 seconds.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(h *httpServer) {
+		h.idleTimeout = d
+	}
+}
+
+// WithMaxHeaderBytes sets the maximum size of request headers. Zero uses
+// http.DefaultMaxHeaderBytes.
+func WithMaxHeaderBytes(n int) Option {
+	return func(h *httpServer) {
+		h.maxHeaderBytes = n
+	}
+}
+
+// WithTLSConfig serves TLS using c. Certificates may be set in c or with
+// WithTLSCertFiles.
+func WithTLSConfig(c *tls.Config) Option {
+	return func(h *httpServer) {
+		h.tlsConfig = c
+	}
+}
+
+// WithTLSCertFiles serves TLS using the certificate and key in certFile and
+// keyFile.
+func WithTLSCertFiles(certFile, keyFile string) Option {
+	return func(h *httpServer) {
+		h.certFile = certFile
+		h.keyFile = keyFile
+	}
+}
+
+// WithDrainTimeout sets how long shutdown waits for in-flight requests to
+// finish before closing their connections. Defaults to 30 seconds.
+func WithDrainTimeout(d time.Duration) Option {
+	return func(h *httpServer) {
+		h.drainTimeout = d
+	}
+}
+
+// WithHealth makes readiness of health fail as soon as shutdown begins. The
+// health endpoints still have to be routed by the handler passed to Start or
+// an additional listener. Use WithShutdownDelay to give load balancers time
+// to see it.
+func WithHealth(health *Health) Option {
+	return func(h *httpServer) {
+		h.health = health
+	}
+}
+
+// WithShutdownDelay keeps serving for d after shutdown begins and readiness
+// fails, before listeners are closed and in-flight requests drained, so load
+// balancers and kubelets polling readiness stop sending traffic first.
+func WithShutdownDelay(d time.Duration) Option {
+	return func(h *httpServer) {
+		h.shutdownDelay = d
+	}
+}
+
+// WithMiddlewares wraps the handler passed to Start with middlewares, the
+// first being the outermost.
+func WithMiddlewares(middlewares ...Middleware) Option {
+	return func(h *httpServer) {
+		h.middlewares = append(h.middlewares, middlewares...)
+	}
+}
+
+// WithListener serves the handler passed to Start on listener instead of
+// listening on host and port, e.g. a listener from SystemdListeners.
+func WithListener(listener net.Listener) Option {
+	return func(h *httpServer) {
+		h.listener = listener
+	}
+}
+
+// WithAdditionalListener serves handler on listener alongside the primary
+// listener. It is served without the middlewares, TLS settings and read and
+// write timeouts of the server.
+func WithAdditionalListener(listener net.Listener, handler http.Handler) Option {
+	return func(h *httpServer) {
+		h.listeners = append(h.listeners, listenerConfig{listener: listener, handler: handler})
+	}
+}
+
+// WithAdditionalAddr serves handler on network and address, e.g. "tcp" and
+// "127.0.0.1:9090" or "unix" and "/run/app/admin.sock", alongside the primary
+// listener. It is served without the middlewares, TLS settings and read and
+// write timeouts of the server.
+func WithAdditionalAddr(network, address string, handler http.Handler) Option {
+	return func(h *httpServer) {
+		h.listeners = append(h.listeners, listenerConfig{network: network, address: address, handler: handler})
+	}
+}
+
+// WithAdminServer serves handler, e.g. from NewAdminHandler, on an internal
+// host and port.
+func WithAdminServer(host string, port int, handler http.Handler) Option {
+	return WithAdditionalAddr("tcp", net.JoinHostPort(host, strconv.Itoa(port)), handler)
+}
+
+// WithGracefulRestart restarts the process without dropping connections on
+// SIGUSR2: a new process of the same executable inherits the listeners, and
+// once it serves them within timeout this one drains and Start returns. Zero
+// uses 30 seconds. The new process must be configured with the same
+// listeners and WithGracefulRestart. It has no effect on systems without
+// SIGUSR2, such as Windows.
+func WithGracefulRestart(timeout time.Duration) Option {
+	return func(h *httpServer) {
+		h.gracefulRestart = true
+
+		if timeout > 0 {
+			h.restartTimeout = timeout
+		}
+	}
+}
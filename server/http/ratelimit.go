@@ -0,0 +1,382 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ranefattesingh/pkg/auth/jwt"
+	"github.com/ranefattesingh/pkg/json"
+	"go.uber.org/zap"
+)
+
+const (
+	TokenBucket   RateLimitAlgorithm = "token_bucket"
+	SlidingWindow RateLimitAlgorithm = "sliding_window"
+
+	minRateLimitSweepInterval = time.Minute
+)
+
+var (
+	ErrUnknownRateLimitAlgorithm = errors.New("unknown rate limit algorithm")
+	ErrInvalidRateLimitPolicy    = errors.New("invalid rate limit policy")
+)
+
+// RateLimitAlgorithm decides how a MemoryStore counts requests.
+type RateLimitAlgorithm string
+
+// RateLimitPolicy allows Requests per Window for every key.
+type RateLimitPolicy struct {
+	Requests int
+	Window   time.Duration
+}
+
+// validate reports policies no request could be counted against.
+func (p RateLimitPolicy) validate() error {
+	if p.Requests <= 0 || p.Window <= 0 {
+		return fmt.Errorf("%w: %d requests per %s", ErrInvalidRateLimitPolicy, p.Requests, p.Window)
+	}
+
+	return nil
+}
+
+// RateLimitResult is the state of the limit of a key after a request.
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the limit is fully restored.
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed. It is zero
+	// for allowed requests.
+	RetryAfter time.Duration
+}
+
+// RateLimitStore counts requests per key. Distributed backends implement it
+// to share limits between instances.
+type RateLimitStore interface {
+	// Take counts one request for key against policy.
+	Take(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error)
+}
+
+// RateLimitKeyFunc returns the key requests are counted under. An empty key
+// falls back to the client IP.
+type RateLimitKeyFunc func(r *http.Request) string
+
+// RateLimitConfig configures RateLimit.
+type RateLimitConfig struct {
+	Policy RateLimitPolicy
+	// Key defaults to KeyByIP.
+	Key RateLimitKeyFunc
+	// Store defaults to an in-memory token bucket store.
+	Store RateLimitStore
+}
+
+// RateLimit rejects requests over the policy of c with 429 and a json.Error,
+// setting Retry-After. Every response carries the RateLimit-Limit,
+// RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy headers. Requests
+// are let through if the store fails. It fails with ErrInvalidRateLimitPolicy
+// unless the policy allows a positive number of requests per window of at
+// least a second, the unit of the headers.
+func RateLimit(c RateLimitConfig) (Middleware, error) {
+	if err := c.Policy.validate(); err != nil {
+		return nil, err
+	}
+
+	if c.Policy.Window < time.Second {
+		return nil, fmt.Errorf("%w: window %s is shorter than a second", ErrInvalidRateLimitPolicy, c.Policy.Window)
+	}
+
+	key := c.Key
+	if key == nil {
+		key = KeyByIP()
+	}
+
+	store := c.Store
+	if store == nil {
+		store, _ = NewMemoryStore(TokenBucket)
+	}
+
+	policy := strconv.Itoa(c.Policy.Requests) + ";w=" + strconv.Itoa(ceilSeconds(c.Policy.Window))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			k := key(r)
+			if k == "" {
+				k = clientIPKey(r)
+			}
+
+			result, err := store.Take(r.Context(), k, c.Policy)
+			if err != nil {
+				requestLogger(r.Context()).Error("rate limit store failed", zap.Error(err))
+				next.ServeHTTP(rw, r)
+
+				return
+			}
+
+			rw.Header().Set("RateLimit-Policy", policy)
+			rw.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			rw.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			rw.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+			if !result.Allowed {
+				rw.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+				rw.Header().Set("Content-Type", "application/json")
+				_ = json.EncodeErrorJSON(rw, &json.Error{
+					HTTPStatusCode: http.StatusTooManyRequests,
+					Code:           http.StatusTooManyRequests,
+					Message:        "rate limit exceeded",
+				})
+
+				return
+			}
+
+			next.ServeHTTP(rw, r)
+		})
+	}, nil
+}
+
+// KeyByIP counts requests per client IP taken from the connection. Behind a
+// proxy use KeyByHeader with the header the proxy sets instead.
+func KeyByIP() RateLimitKeyFunc {
+	return clientIPKey
+}
+
+// KeyByHeader counts requests per value of the header name, e.g. an API key.
+func KeyByHeader(name string) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		value := r.Header.Get(name)
+		if value == "" {
+			return ""
+		}
+
+		return strings.ToLower(name) + ":" + value
+	}
+}
+
+// KeyByJWTSubject counts requests per subject of the bearer token in the
+// Authorization header. Requests without a valid token are counted by IP.
+func KeyByJWTSubject(j *jwt.JWT) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			return ""
+		}
+
+		claims, valid, err := j.Parse(strings.TrimSpace(token))
+		if err != nil || !valid {
+			return ""
+		}
+
+		subject, err := claims.GetSubject()
+		if err != nil || subject == "" {
+			return ""
+		}
+
+		return "sub:" + subject
+	}
+}
+
+func clientIPKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// MemoryStore is a RateLimitStore keeping the limits in process memory. Keys
+// idle for longer than their window are evicted.
+type MemoryStore struct {
+	algorithm RateLimitAlgorithm
+	now       func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]*rateLimitEntry
+	lastSweep time.Time
+}
+
+type rateLimitEntry struct {
+	window   time.Duration
+	lastSeen time.Time
+
+	// Token bucket state.
+	tokens     float64
+	lastRefill time.Time
+
+	// Sliding window state.
+	windowStart   time.Time
+	currentCount  int
+	previousCount int
+}
+
+// NewMemoryStore returns a MemoryStore counting requests with algorithm.
+func NewMemoryStore(algorithm RateLimitAlgorithm) (*MemoryStore, error) {
+	switch algorithm {
+	case TokenBucket, SlidingWindow:
+	default:
+		return nil, ErrUnknownRateLimitAlgorithm
+	}
+
+	return &MemoryStore{
+		algorithm: algorithm,
+		now:       time.Now,
+		entries:   make(map[string]*rateLimitEntry),
+	}, nil
+}
+
+// Take counts one request for key against policy. It fails with
+// ErrInvalidRateLimitPolicy for policies RateLimit would reject.
+func (s *MemoryStore) Take(_ context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error) {
+	if err := policy.validate(); err != nil {
+		return RateLimitResult{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now, policy.Window)
+
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &rateLimitEntry{
+			tokens:      float64(policy.Requests),
+			lastRefill:  now,
+			windowStart: now,
+		}
+		s.entries[key] = entry
+	}
+
+	entry.window = policy.Window
+	entry.lastSeen = now
+
+	if s.algorithm == SlidingWindow {
+		return entry.takeSlidingWindow(now, policy), nil
+	}
+
+	return entry.takeTokenBucket(now, policy), nil
+}
+
+// sweep evicts idle entries at most once per window, and at least a minute
+// apart, so the map does not grow with every client ever seen.
+func (s *MemoryStore) sweep(now time.Time, window time.Duration) {
+	interval := max(window, minRateLimitSweepInterval)
+	if now.Sub(s.lastSweep) < interval {
+		return
+	}
+
+	s.lastSweep = now
+
+	for key, entry := range s.entries {
+		// Both algorithms have fully restored the limit after two windows.
+		if now.Sub(entry.lastSeen) > 2*entry.window {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// takeTokenBucket refills Requests tokens per Window up to Requests and takes
+// one token per request.
+func (e *rateLimitEntry) takeTokenBucket(now time.Time, policy RateLimitPolicy) RateLimitResult {
+	capacity := float64(policy.Requests)
+	rate := capacity / policy.Window.Seconds()
+
+	e.tokens = math.Min(capacity, e.tokens+now.Sub(e.lastRefill).Seconds()*rate)
+	e.lastRefill = now
+
+	result := RateLimitResult{Limit: policy.Requests}
+
+	if e.tokens >= 1 {
+		e.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsDuration((1 - e.tokens) / rate)
+	}
+
+	result.Remaining = int(e.tokens)
+	result.Reset = secondsDuration((capacity - e.tokens) / rate)
+
+	return result
+}
+
+// takeSlidingWindow estimates the requests of the last Window by weighting
+// the count of the previous fixed window by how much of it still overlaps.
+func (e *rateLimitEntry) takeSlidingWindow(now time.Time, policy RateLimitPolicy) RateLimitResult {
+	window := policy.Window
+
+	if elapsed := now.Sub(e.windowStart); elapsed >= window {
+		windows := elapsed / window
+
+		e.previousCount = e.currentCount
+		if windows > 1 {
+			e.previousCount = 0
+		}
+
+		e.currentCount = 0
+		e.windowStart = e.windowStart.Add(windows * window)
+	}
+
+	elapsed := now.Sub(e.windowStart)
+	overlap := 1 - elapsed.Seconds()/window.Seconds()
+	estimate := float64(e.previousCount)*overlap + float64(e.currentCount)
+
+	result := RateLimitResult{
+		Limit: policy.Requests,
+		Reset: window - elapsed,
+	}
+
+	if estimate+1 <= float64(policy.Requests) {
+		e.currentCount++
+		estimate++
+		result.Allowed = true
+	} else {
+		result.RetryAfter = e.slidingWindowRetryAfter(elapsed, policy)
+	}
+
+	// Requests of the current window count until the end of the next one.
+	if e.currentCount > 0 {
+		result.Reset += window
+	}
+
+	result.Remaining = max(0, policy.Requests-int(math.Ceil(estimate)))
+
+	return result
+}
+
+// slidingWindowRetryAfter returns when the estimate drops enough for one
+// more request, either later in the current window as the previous one
+// slides out, or in the next window.
+func (e *rateLimitEntry) slidingWindowRetryAfter(elapsed time.Duration, policy RateLimitPolicy) time.Duration {
+	window := policy.Window.Seconds()
+	allowed := float64(policy.Requests - 1)
+
+	if e.previousCount > 0 && allowed >= float64(e.currentCount) {
+		at := window * (1 - (allowed-float64(e.currentCount))/float64(e.previousCount))
+
+		return secondsDuration(at - elapsed.Seconds())
+	}
+
+	next := policy.Window - elapsed
+	if e.currentCount > 0 {
+		next += secondsDuration(math.Max(0, window*(1-allowed/float64(e.currentCount))))
+	}
+
+	return next
+}
+
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,326 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/ranefattesingh/pkg/auth/jwt"
+	httpserver "github.com/ranefattesingh/pkg/server/http"
+)
+
+func TestRateLimit(t *testing.T) {
+	t.Parallel()
+
+	testTable := map[string]struct {
+		algorithm          httpserver.RateLimitAlgorithm
+		expectedRetryAfter string
+		expectedReset      string
+	}{
+		"should limit with a token bucket": {
+			algorithm:          httpserver.TokenBucket,
+			expectedRetryAfter: "1800",
+			expectedReset:      "3600",
+		},
+		"should limit with a sliding window": {
+			algorithm:          httpserver.SlidingWindow,
+			expectedRetryAfter: "5400",
+			expectedReset:      "7200",
+		},
+	}
+
+	for scenario, tc := range testTable {
+		t.Run(scenario, func(t *testing.T) {
+			t.Parallel()
+
+			store, err := httpserver.NewMemoryStore(tc.algorithm)
+			if err != nil {
+				t.Fatalf("NewMemoryStore() err = %v", err)
+			}
+
+			handler := httpserver.Chain(http.NotFoundHandler(), rateLimit(t, httpserver.RateLimitConfig{
+				Policy: httpserver.RateLimitPolicy{Requests: 2, Window: time.Hour},
+				Store:  store,
+			}))
+
+			serve := func(remoteAddr string) *httptest.ResponseRecorder {
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.RemoteAddr = remoteAddr
+
+				rw := httptest.NewRecorder()
+				handler.ServeHTTP(rw, r)
+
+				return rw
+			}
+
+			for i, expectedRemaining := range []string{"1", "0"} {
+				rw := serve("10.0.0.1:1234")
+				if rw.Code == http.StatusTooManyRequests {
+					t.Fatalf("request %d was limited", i)
+				}
+
+				if remaining := rw.Header().Get("RateLimit-Remaining"); remaining != expectedRemaining {
+					t.Errorf("request %d RateLimit-Remaining = %s, expected %s", i, remaining, expectedRemaining)
+				}
+			}
+
+			rw := serve("10.0.0.1:5678")
+			if rw.Code != http.StatusTooManyRequests {
+				t.Fatalf("status = %d, expected %d", rw.Code, http.StatusTooManyRequests)
+			}
+
+			expectedHeaders := map[string]string{
+				"Retry-After":         tc.expectedRetryAfter,
+				"RateLimit-Limit":     "2",
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     tc.expectedReset,
+				"RateLimit-Policy":    "2;w=3600",
+			}
+
+			for key, expected := range expectedHeaders {
+				if actual := rw.Header().Get(key); actual != expected {
+					t.Errorf("%s = %s, expected %s", key, actual, expected)
+				}
+			}
+
+			var body struct {
+				Success bool `json:"success"`
+				Error   struct {
+					Code int `json:"code"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(rw.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Unmarshal() err = %v", err)
+			}
+
+			if body.Success || body.Error.Code != http.StatusTooManyRequests {
+				t.Errorf("body = %s, expected a 429 error response", rw.Body.String())
+			}
+
+			if rw := serve("10.0.0.2:1234"); rw.Code == http.StatusTooManyRequests {
+				t.Errorf("another client was limited")
+			}
+		})
+	}
+}
+
+func TestRateLimitRefill(t *testing.T) {
+	t.Parallel()
+
+	store, err := httpserver.NewMemoryStore(httpserver.TokenBucket)
+	if err != nil {
+		t.Fatalf("NewMemoryStore() err = %v", err)
+	}
+
+	policy := httpserver.RateLimitPolicy{Requests: 1, Window: 100 * time.Millisecond}
+
+	take := func() bool {
+		result, err := store.Take(context.Background(), "key", policy)
+		if err != nil {
+			t.Fatalf("Take() err = %v", err)
+		}
+
+		return result.Allowed
+	}
+
+	if !take() {
+		t.Fatalf("first request was limited")
+	}
+
+	if take() {
+		t.Fatalf("second request was allowed, expected it to be limited")
+	}
+
+	time.Sleep(150 * time.Millisecond)
+
+	if !take() {
+		t.Errorf("request after the window was limited")
+	}
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	t.Parallel()
+
+	signer := jwt.NewJWT(gojwt.SigningMethodHS256, "secret")
+
+	token := func(subject string) string {
+		signed, err := signer.GenerateToken(gojwt.RegisteredClaims{Subject: subject})
+		if err != nil {
+			t.Fatalf("GenerateToken() err = %v", err)
+		}
+
+		return "Bearer " + signed
+	}
+
+	testTable := map[string]struct {
+		key            httpserver.RateLimitKeyFunc
+		header         string
+		first          string
+		second         string
+		expectedShared bool
+	}{
+		"should share the limit of a header value": {
+			key:            httpserver.KeyByHeader("X-API-Key"),
+			header:         "X-API-Key",
+			first:          "key-1",
+			second:         "key-1",
+			expectedShared: true,
+		},
+		"should separate header values": {
+			key:    httpserver.KeyByHeader("X-API-Key"),
+			header: "X-API-Key",
+			first:  "key-1",
+			second: "key-2",
+		},
+		"should share the limit of a JWT subject across tokens": {
+			key:            httpserver.KeyByJWTSubject(signer),
+			header:         "Authorization",
+			first:          token("user-1"),
+			second:         token("user-1") + " ",
+			expectedShared: true,
+		},
+		"should separate JWT subjects": {
+			key:    httpserver.KeyByJWTSubject(signer),
+			header: "Authorization",
+			first:  token("user-1"),
+			second: token("user-2"),
+		},
+		"should fall back to the client IP for invalid tokens": {
+			key:            httpserver.KeyByJWTSubject(signer),
+			header:         "Authorization",
+			first:          "Bearer invalid",
+			second:         "Bearer also-invalid",
+			expectedShared: true,
+		},
+	}
+
+	for scenario, tc := range testTable {
+		t.Run(scenario, func(t *testing.T) {
+			t.Parallel()
+
+			handler := httpserver.Chain(http.NotFoundHandler(), rateLimit(t, httpserver.RateLimitConfig{
+				Policy: httpserver.RateLimitPolicy{Requests: 1, Window: time.Hour},
+				Key:    tc.key,
+			}))
+
+			serve := func(value string) int {
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.Header.Set(tc.header, value)
+
+				rw := httptest.NewRecorder()
+				handler.ServeHTTP(rw, r)
+
+				return rw.Code
+			}
+
+			serve(tc.first)
+
+			if shared := serve(tc.second) == http.StatusTooManyRequests; shared != tc.expectedShared {
+				t.Errorf("shared = %v, expected %v", shared, tc.expectedShared)
+			}
+		})
+	}
+}
+
+func TestRateLimitStoreFailure(t *testing.T) {
+	t.Parallel()
+
+	handler := httpserver.Chain(http.NotFoundHandler(), rateLimit(t, httpserver.RateLimitConfig{
+		Policy: httpserver.RateLimitPolicy{Requests: 1, Window: time.Hour},
+		Store:  failingStore{},
+	}))
+
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rw.Code != http.StatusNotFound {
+		t.Errorf("status = %d, expected %d", rw.Code, http.StatusNotFound)
+	}
+}
+
+func TestNewMemoryStoreUnknownAlgorithm(t *testing.T) {
+	t.Parallel()
+
+	if _, err := httpserver.NewMemoryStore("leaky"); !errors.Is(err, httpserver.ErrUnknownRateLimitAlgorithm) {
+		t.Errorf("NewMemoryStore() err = %v, expected %v", err, httpserver.ErrUnknownRateLimitAlgorithm)
+	}
+}
+
+func TestRateLimitInvalidPolicy(t *testing.T) {
+	t.Parallel()
+
+	testTable := map[string]struct {
+		policy       httpserver.RateLimitPolicy
+		expectTakeOK bool
+	}{
+		"should reject a zero policy":       {policy: httpserver.RateLimitPolicy{}},
+		"should reject zero requests":       {policy: httpserver.RateLimitPolicy{Requests: 0, Window: time.Minute}},
+		"should reject negative requests":   {policy: httpserver.RateLimitPolicy{Requests: -1, Window: time.Minute}},
+		"should reject a zero window":       {policy: httpserver.RateLimitPolicy{Requests: 10}},
+		"should reject a negative window":   {policy: httpserver.RateLimitPolicy{Requests: 10, Window: -time.Minute}},
+		"should reject a sub-second window": {policy: httpserver.RateLimitPolicy{Requests: 10, Window: 500 * time.Millisecond}, expectTakeOK: true},
+	}
+
+	for scenario, tc := range testTable {
+		t.Run(scenario, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := httpserver.RateLimit(httpserver.RateLimitConfig{Policy: tc.policy}); !errors.Is(err, httpserver.ErrInvalidRateLimitPolicy) {
+				t.Errorf("RateLimit() err = %v, expected %v", err, httpserver.ErrInvalidRateLimitPolicy)
+			}
+
+			for _, algorithm := range []httpserver.RateLimitAlgorithm{httpserver.TokenBucket, httpserver.SlidingWindow} {
+				store, err := httpserver.NewMemoryStore(algorithm)
+				if err != nil {
+					t.Fatalf("NewMemoryStore() err = %v", err)
+				}
+
+				_, err = store.Take(context.Background(), "key", tc.policy)
+				if tc.expectTakeOK && err != nil {
+					t.Errorf("Take() with %s err = %v, expected nil", algorithm, err)
+				}
+
+				if !tc.expectTakeOK && !errors.Is(err, httpserver.ErrInvalidRateLimitPolicy) {
+					t.Errorf("Take() with %s err = %v, expected %v", algorithm, err, httpserver.ErrInvalidRateLimitPolicy)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitPolicyHeader(t *testing.T) {
+	t.Parallel()
+
+	handler := httpserver.Chain(http.NotFoundHandler(), rateLimit(t, httpserver.RateLimitConfig{
+		Policy: httpserver.RateLimitPolicy{Requests: 10, Window: 1500 * time.Millisecond},
+	}))
+
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if policy := rw.Header().Get("RateLimit-Policy"); policy != "10;w=2" {
+		t.Errorf("RateLimit-Policy = %q, expected %q", policy, "10;w=2")
+	}
+}
+
+func rateLimit(t *testing.T, c httpserver.RateLimitConfig) httpserver.Middleware {
+	t.Helper()
+
+	middleware, err := httpserver.RateLimit(c)
+	if err != nil {
+		t.Fatalf("RateLimit() err = %v", err)
+	}
+
+	return middleware
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, httpserver.RateLimitPolicy) (httpserver.RateLimitResult, error) {
+	return httpserver.RateLimitResult{}, errors.New("store unavailable")
+}
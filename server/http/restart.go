@@ -0,0 +1,165 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+const (
+	// envRestartListenFDs holds the number of listeners a restarting parent
+	// passed to its child. The listeners start at file descriptor 3 and are
+	// followed by the pipe the child reports readiness on.
+	envRestartListenFDs = "HTTP_SERVER_LISTEN_FDS"
+
+	restartListenFDsStart = 3
+	defaultRestartTimeout = 30 * time.Second
+)
+
+var (
+	ErrRestartNotReady        = errors.New("restarted process did not become ready")
+	ErrInheritedListenerCount = errors.New("inherited listeners do not match the configured listeners")
+)
+
+// filer is implemented by the listeners of package net whose file
+// descriptor can be passed to a child process.
+type filer interface {
+	File() (*os.File, error)
+}
+
+// inheritedListeners returns the listeners passed by a restarting parent,
+// or nil if the process was not started by one. The readiness pipe is kept
+// to be signalled by notifyReady.
+func (h *httpServer) inheritedListeners() ([]net.Listener, error) {
+	if !h.gracefulRestart {
+		return nil, nil
+	}
+
+	count, err := strconv.Atoi(os.Getenv(envRestartListenFDs))
+	if err != nil || count == 0 {
+		return nil, nil
+	}
+
+	// Children of this process must not mistake the variable for their own.
+	_ = os.Unsetenv(envRestartListenFDs)
+
+	h.readyFile = os.NewFile(uintptr(restartListenFDsStart+count), "restart-ready")
+
+	return filesListeners(restartListenFDsStart, count)
+}
+
+// notifyReady tells the parent that every listener is served so it can
+// start draining.
+func (h *httpServer) notifyReady() error {
+	if h.readyFile == nil {
+		return nil
+	}
+
+	_, err := h.readyFile.Write([]byte{1})
+
+	return errors.Join(err, h.readyFile.Close())
+}
+
+// restart starts a new process of the current executable with the same
+// arguments, passing it the file descriptors of listeners, and waits until
+// it reports that it serves them. If the child fails to do so within the
+// restart timeout, or ctx is done first, it is killed and this process keeps
+// serving.
+func (h *httpServer) restart(ctx context.Context, listeners []boundListener) (err error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	files := make([]*os.File, 0, len(listeners)+1)
+	unixListeners := make([]*net.UnixListener, 0)
+
+	defer func() {
+		for _, file := range files {
+			_ = file.Close()
+		}
+
+		// Without a child the socket files are removed on shutdown again.
+		if err != nil {
+			for _, unixListener := range unixListeners {
+				unixListener.SetUnlinkOnClose(true)
+			}
+		}
+	}()
+
+	for _, l := range listeners {
+		// The socket file has to outlive this process for the child.
+		if unixListener, ok := l.listener.(*net.UnixListener); ok {
+			unixListener.SetUnlinkOnClose(false)
+			unixListeners = append(unixListeners, unixListener)
+		}
+
+		f, ok := l.listener.(filer)
+		if !ok {
+			return fmt.Errorf("listener %s cannot be passed to a child process", l.listener.Addr())
+		}
+
+		file, err := f.File()
+		if err != nil {
+			return err
+		}
+
+		files = append(files, file)
+	}
+
+	readyReader, readyWriter, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer readyReader.Close()
+
+	files = append(files, readyWriter)
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), envRestartListenFDs+"="+strconv.Itoa(len(listeners)))
+	cmd.ExtraFiles = files
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// Without the copy of this process, reading fails once the child exits.
+	_ = readyWriter.Close()
+
+	ready := make(chan error, 1)
+
+	go func() {
+		_, err := readyReader.Read(make([]byte, 1))
+		ready <- err
+	}()
+
+	select {
+	case err = <-ready:
+	case <-time.After(h.restartTimeout):
+		err = os.ErrDeadlineExceeded
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+
+		return fmt.Errorf("%w: %w", ErrRestartNotReady, err)
+	}
+
+	// The child outlives this process, reap it if it exits before.
+	go func() {
+		_ = cmd.Wait()
+	}()
+
+	return nil
+}
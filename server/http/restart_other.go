@@ -0,0 +1,9 @@
+//go:build !unix
+
+package http
+
+import "os"
+
+// restartSignal is nil where there is no SIGUSR2, so WithGracefulRestart has
+// no effect.
+var restartSignal os.Signal
@@ -0,0 +1,160 @@
+//go:build unix
+
+package http_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	httpserver "github.com/ranefattesingh/pkg/server/http"
+)
+
+const (
+	envRestartTestChild = "HTTP_SERVER_RESTART_TEST_CHILD"
+	envRestartTestPort  = "HTTP_SERVER_RESTART_TEST_PORT"
+	envRestartTestHang  = "HTTP_SERVER_RESTART_TEST_HANG"
+)
+
+// TestMain runs the children of the graceful restart tests when the test
+// binary is restarted by them.
+func TestMain(m *testing.M) {
+	if os.Getenv(envRestartTestHang) != "" {
+		// Never reports readiness, the parent is expected to kill it.
+		time.Sleep(time.Minute)
+		os.Exit(1)
+	}
+
+	if os.Getenv(envRestartTestChild) != "" {
+		os.Exit(runRestartChild())
+	}
+
+	os.Exit(m.Run())
+}
+
+func runRestartChild() int {
+	port, err := strconv.Atoi(os.Getenv(envRestartTestPort))
+	if err != nil {
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(rw http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(rw, "child")
+	})
+	mux.HandleFunc("/exit", func(rw http.ResponseWriter, r *http.Request) {
+		cancel()
+	})
+
+	server := httpserver.NewHTTPServer("127.0.0.1", port, httpserver.WithGracefulRestart(0))
+	if err := server.StartContext(ctx, mux, nil); err != nil {
+		return 1
+	}
+
+	return 0
+}
+
+func TestGracefulRestart(t *testing.T) {
+	host, port := freeAddr(t)
+
+	t.Setenv(envRestartTestChild, "1")
+	t.Setenv(envRestartTestPort, strconv.Itoa(port))
+
+	server := httpserver.NewHTTPServer(host, port, httpserver.WithGracefulRestart(5*time.Second))
+
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- server.StartContext(context.Background(), http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(rw, "parent")
+		}), nil)
+	}()
+
+	waitForServer(t, host, port)
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	url := "http://" + net.JoinHostPort(host, strconv.Itoa(port))
+
+	get := func(path string) string {
+		t.Helper()
+
+		resp, err := client.Get(url + path)
+		if err != nil {
+			t.Fatalf("Get() err = %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+
+		return string(body)
+	}
+
+	if body := get("/"); body != "parent" {
+		t.Fatalf("body = %q, expected %q", body, "parent")
+	}
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR2); err != nil {
+		t.Fatalf("Kill() err = %v", err)
+	}
+
+	select {
+	case err := <-errChan:
+		if err != nil {
+			t.Fatalf("StartContext() err = %v, expected nil", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("StartContext() did not return after the restart")
+	}
+
+	// The parent has drained; the port is still served, by the child.
+	if body := get("/"); body != "child" {
+		t.Errorf("body = %q, expected %q", body, "child")
+	}
+
+	get("/exit")
+}
+
+func TestGracefulRestartAbortedOnShutdown(t *testing.T) {
+	host, port := freeAddr(t)
+
+	t.Setenv(envRestartTestHang, "1")
+
+	server := httpserver.NewHTTPServer(host, port, httpserver.WithGracefulRestart(time.Minute))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- server.StartContext(ctx, http.NotFoundHandler(), nil)
+	}()
+
+	waitForServer(t, host, port)
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR2); err != nil {
+		t.Fatalf("Kill() err = %v", err)
+	}
+
+	// Let the restart start waiting for the child.
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errChan:
+		if err != nil {
+			t.Errorf("StartContext() err = %v, expected nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("StartContext() did not return while a restart was pending")
+	}
+}
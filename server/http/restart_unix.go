@@ -0,0 +1,10 @@
+//go:build unix
+
+package http
+
+import (
+	"os"
+	"syscall"
+)
+
+var restartSignal os.Signal = syscall.SIGUSR2
@@ -2,99 +2,277 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"sync"
 	"syscall"
+	"time"
+
+	"github.com/ranefattesingh/pkg/log"
+	"go.uber.org/zap"
 )
 
+// serverLoggerName is the logger of lifecycle events, apart from the access
+// log.
+const serverLoggerName = "http.server"
+
 type httpServer struct {
-	addr             string
-	server           *http.Server
-	cleanupFns       []func() error
-	failedCleanupFns []func() error
+	addr       string
+	listener   net.Listener
+	listeners  []listenerConfig
+	servers    []*http.Server
+	cleanupFns []func() error
+
+	readTimeout       time.Duration
+	readHeaderTimeout time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	maxHeaderBytes    int
+	drainTimeout      time.Duration
+	shutdownDelay     time.Duration
+
+	tlsConfig *tls.Config
+	certFile  string
+	keyFile   string
+
+	health      *Health
+	middlewares []Middleware
+
+	gracefulRestart bool
+	restartTimeout  time.Duration
+	readyFile       *os.File
 }
 
-func NewHTTPServer(host string, port int, cleanupFns ...func() error) *httpServer {
-	return &httpServer{
-		addr:             host + ":" + strconv.Itoa(port),
-		cleanupFns:       cleanupFns,
-		failedCleanupFns: make([]func() error, 0),
+func NewHTTPServer(host string, port int, opts ...Option) *httpServer {
+	h := &httpServer{
+		addr:              host + ":" + strconv.Itoa(port),
+		cleanupFns:        make([]func() error, 0),
+		readHeaderTimeout: defaultReadHeaderTimeout,
+		idleTimeout:       defaultIdleTimeout,
+		drainTimeout:      defaultDrainTimeout,
+		restartTimeout:    defaultRestartTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(h)
 	}
+
+	return h
 }
 
-func (h *httpServer) start(handler http.Handler) error {
-	h.server = &http.Server{
-		Addr:    h.addr,
-		Handler: handler,
+// newServer returns the server of l. Additional listeners keep the header
+// and idle timeouts but not the read and write timeouts of the primary one,
+// which would cut off long requests such as CPU profiles on an admin server.
+func (h *httpServer) newServer(l boundListener) *http.Server {
+	server := &http.Server{
+		Addr:              h.addr,
+		Handler:           l.handler,
+		ReadHeaderTimeout: h.readHeaderTimeout,
+		IdleTimeout:       h.idleTimeout,
+		MaxHeaderBytes:    h.maxHeaderBytes,
+	}
+
+	if l.primary {
+		server.ReadTimeout = h.readTimeout
+		server.WriteTimeout = h.writeTimeout
+	}
+
+	if l.tls {
+		server.TLSConfig = h.tlsConfig
 	}
 
-	return h.server.ListenAndServe()
+	return server
 }
 
-func (h *httpServer) shutdown(shutdown chan<- error, cancelFn context.CancelFunc,
-) {
-	defer close(shutdown)
+func (h *httpServer) serve(server *http.Server, listener net.Listener, useTLS bool) error {
+	if useTLS {
+		return server.ServeTLS(listener, h.certFile, h.keyFile)
+	}
 
-	sigChan := make(chan os.Signal, 1)
+	return server.Serve(listener)
+}
 
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+func (h *httpServer) usesTLS() bool {
+	return h.tlsConfig != nil || h.certFile != ""
+}
 
-	<-sigChan
+// Start serves handler until SIGINT or SIGTERM is received, then shuts the
+// server down. cancelFn is called once in-flight requests have drained.
+func (h *httpServer) Start(handler http.Handler, cancelFn context.CancelFunc) error {
+	return h.StartContext(context.Background(), handler, cancelFn)
+}
+
+// StartContext serves handler, and the handlers of additional listeners,
+// until ctx is done, SIGINT or SIGTERM is received or a listener fails, then
+// shuts every listener down together. With WithGracefulRestart it also
+// returns after handing its listeners to a new process on SIGUSR2, and the
+// caller is expected to exit. An error opening a listener is returned
+// immediately. Otherwise the returned error joins the errors of serving,
+// draining and the cleanup functions. cancelFn, if not nil, is called once
+// in-flight requests have drained.
+func (h *httpServer) StartContext(ctx context.Context, handler http.Handler, cancelFn context.CancelFunc) error {
+	listeners, err := h.listen(handler)
+	if err != nil {
+		return err
+	}
 
-	cancelFn()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Server resources cleanup.
-	for _, cleanupFunction := range h.cleanupFns {
-		if err := cleanupFunction(); err != nil {
-			h.failedCleanupFns = append(h.failedCleanupFns, cleanupFunction)
+	h.servers = make([]*http.Server, 0, len(listeners))
+	serveErrChan := make(chan error, len(listeners))
+
+	for _, l := range listeners {
+		server := h.newServer(l)
+		h.servers = append(h.servers, server)
+
+		go func() {
+			serveErrChan <- h.serve(server, l.listener, l.tls)
+		}()
+	}
+
+	if err := h.notifyReady(); err != nil {
+		log.Named(serverLoggerName).Error("failed to notify the restarting parent", zap.Error(err))
+	}
+
+	var restartChan chan os.Signal
+
+	if h.gracefulRestart && restartSignal != nil {
+		restartChan = make(chan os.Signal, 1)
+		signal.Notify(restartChan, restartSignal)
+
+		defer signal.Stop(restartChan)
+	}
+
+	// A restart runs aside so that signals and serve errors are still
+	// handled while the new process starts. It is aborted on shutdown.
+	restartCtx, cancelRestart := context.WithCancel(ctx)
+	defer cancelRestart()
+
+	var (
+		serveErr    error
+		restartDone chan error
+	)
+
+serving:
+	for {
+		select {
+		case err := <-serveErrChan:
+			if !errors.Is(err, http.ErrServerClosed) {
+				serveErr = err
+			}
+
+			break serving
+		case <-ctx.Done():
+			break serving
+		case <-restartChan:
+			if restartDone != nil {
+				continue
+			}
+
+			restartDone = make(chan error, 1)
+
+			go func() {
+				restartDone <- h.restart(restartCtx, listeners)
+			}()
+		case err := <-restartDone:
+			restartDone = nil
+
+			if err != nil {
+				log.Named(serverLoggerName).Error("graceful restart failed", zap.Error(err))
+
+				continue
+			}
+
+			break serving
 		}
 	}
 
-	shutdownErr := h.shutdownHTTPServer()
+	if restartDone != nil {
+		cancelRestart()
+		<-restartDone
+	}
+
+	return errors.Join(serveErr, h.shutdown(cancelFn))
+}
+
+func (h *httpServer) shutdown(cancelFn context.CancelFunc) error {
+	if h.health != nil {
+		h.health.Shutdown()
+	}
+
+	// Keep serving while load balancers notice readiness failing.
+	if h.shutdownDelay > 0 {
+		time.Sleep(h.shutdownDelay)
+	}
+
+	// Stop accepting connections and drain in-flight requests before the
+	// resources they use are released.
+	shutdownErr := h.shutdownHTTPServers()
 
-	retryFailedCleanupFns(shutdown, h.failedCleanupFns)
+	if cancelFn != nil {
+		cancelFn()
+	}
 
-	shutdown <- shutdownErr
+	return errors.Join(shutdownErr, h.cleanup())
 }
 
-func (h *httpServer) shutdownHTTPServer() error {
-	ctx, cancel := context.WithCancel(context.Background())
+// shutdownHTTPServers drains every listener concurrently within one drain
+// timeout.
+func (h *httpServer) shutdownHTTPServers() error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.drainTimeout)
 	defer cancel()
 
-	if h.server != nil {
-		if err := h.server.Shutdown(ctx); err != nil {
-			return err
-		}
+	errs := make([]error, len(h.servers))
+
+	var wg sync.WaitGroup
+
+	wg.Add(len(h.servers))
+
+	for i, server := range h.servers {
+		go func() {
+			defer wg.Done()
+
+			if err := server.Shutdown(ctx); err != nil {
+				// The drain deadline passed, close the remaining connections.
+				_ = server.Close()
+
+				errs[i] = err
+			}
+		}()
 	}
 
-	return nil
-}
+	wg.Wait()
 
-func (h *httpServer) Start(handler http.Handler, cancelFn context.CancelFunc) error {
-	errChan := make(chan error, 1)
+	return errors.Join(errs...)
+}
 
-	go h.shutdown(errChan, cancelFn)
+// cleanup runs the cleanup functions in reverse order of registration, then
+// retries the failed ones once and returns their errors.
+func (h *httpServer) cleanup() error {
+	failedCleanupFns := make([]func() error, 0)
 
-	if err := h.start(handler); errors.Is(err, http.ErrServerClosed) {
-		return err
+	for i := len(h.cleanupFns) - 1; i >= 0; i-- {
+		if err := h.cleanupFns[i](); err != nil {
+			failedCleanupFns = append(failedCleanupFns, h.cleanupFns[i])
+		}
 	}
 
-	return <-errChan
+	return retryFailedCleanupFns(failedCleanupFns)
 }
 
-func retryFailedCleanupFns(shutdown chan<- error, failedCleanupFns []func() error) {
-	// Retry failed cleanup functions
-	errorQ := make([]error, 0)
+func retryFailedCleanupFns(failedCleanupFns []func() error) error {
+	errs := make([]error, 0)
 	for _, cleanupFunction := range failedCleanupFns {
 		if err := cleanupFunction(); err != nil {
-			errorQ = append(errorQ, err)
+			errs = append(errs, err)
 		}
 	}
 
-	for _, err := range errorQ {
-		shutdown <- err
-	}
+	return errors.Join(errs...)
 }
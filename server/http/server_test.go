@@ -0,0 +1,167 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	httpserver "github.com/ranefattesingh/pkg/server/http"
+)
+
+func TestStartReturnsBindError(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() err = %v", err)
+	}
+	defer listener.Close()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	server := httpserver.NewHTTPServer("127.0.0.1", port)
+
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- server.StartContext(context.Background(), http.NotFoundHandler(), nil)
+	}()
+
+	select {
+	case err := <-errChan:
+		if err == nil {
+			t.Errorf("StartContext() err = nil, expected bind error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("StartContext() did not return on bind error")
+	}
+}
+
+func TestStartContextShutdown(t *testing.T) {
+	t.Parallel()
+
+	errCleanup := errors.New("cleanup failed")
+
+	order := make([]string, 0)
+	cleanup := func(name string, err error) func() error {
+		return func() error {
+			order = append(order, name)
+
+			return err
+		}
+	}
+
+	host, port := freeAddr(t)
+	server := httpserver.NewHTTPServer(host, port, httpserver.WithCleanupFns(
+		cleanup("db", nil),
+		cleanup("cache", errCleanup),
+	))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	appCancelled := false
+
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- server.StartContext(ctx, http.NotFoundHandler(), func() {
+			appCancelled = true
+		})
+	}()
+
+	waitForServer(t, host, port)
+	cancel()
+
+	err := <-errChan
+	if !errors.Is(err, errCleanup) {
+		t.Errorf("StartContext() err = %v, expected %v", err, errCleanup)
+	}
+
+	if !appCancelled {
+		t.Errorf("cancelFn was not called")
+	}
+
+	// Cleanup runs in reverse order, then failed functions are retried.
+	if expected := []string{"cache", "db", "cache"}; !reflect.DeepEqual(order, expected) {
+		t.Errorf("cleanup order = %v, expected %v", order, expected)
+	}
+}
+
+func TestShutdownDrainTimeout(t *testing.T) {
+	t.Parallel()
+
+	host, port := freeAddr(t)
+	server := httpserver.NewHTTPServer(host, port, httpserver.WithDrainTimeout(50*time.Millisecond))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- server.StartContext(ctx, handler, nil)
+	}()
+
+	waitForServer(t, host, port)
+
+	go func() {
+		resp, err := http.Get("http://" + net.JoinHostPort(host, strconv.Itoa(port)))
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+
+	<-started
+	cancel()
+
+	select {
+	case err := <-errChan:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("StartContext() err = %v, expected %v", err, context.DeadlineExceeded)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown did not honour the drain timeout")
+	}
+}
+
+func freeAddr(t *testing.T) (string, int) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() err = %v", err)
+	}
+	defer listener.Close()
+
+	return "127.0.0.1", listener.Addr().(*net.TCPAddr).Port
+}
+
+func waitForServer(t *testing.T, host string, port int) {
+	t.Helper()
+
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	deadline := time.Now().Add(5 * time.Second)
+
+	for time.Now().Before(deadline) {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("server at %s did not start", addr)
+}